@@ -4,14 +4,15 @@ go 1.22.2
 
 require (
 	github.com/google/uuid v1.6.0
-	github.com/open-feature/go-sdk v1.10.0
+	github.com/open-feature/go-sdk v1.14.1
 	github.com/spotify/confidence-sdk-go v0.2.2-0.20240523155951-58414ce436ee
 )
 
 require (
-	github.com/go-logr/logr v1.4.1 // indirect
-	golang.org/x/exp v0.0.0-20240213143201-ec583247a57a // indirect
-	google.golang.org/protobuf v1.33.0 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/spotify/confidence-sdk-go => ../
@@ -3,11 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"time"
 
-	"golang.org/x/exp/slog"
-
 	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
 )
 
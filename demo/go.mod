@@ -4,6 +4,11 @@ go 1.22.2
 
 require github.com/spotify/confidence-sdk-go v0.4.1
 
-require golang.org/x/exp v0.0.0-20240213143201-ec583247a57a // indirect
+require github.com/google/go-cmp v0.6.0 // indirect
+
+require (
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
 
 replace github.com/spotify/confidence-sdk-go => ../
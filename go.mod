@@ -1,17 +1,17 @@
 module github.com/spotify/confidence-sdk-go
 
-go 1.19
+go 1.21
 
 require (
-	github.com/open-feature/go-sdk v1.10.0
+	github.com/open-feature/go-sdk v1.14.1
 	github.com/stretchr/testify v1.9.0
-	golang.org/x/exp v0.0.0-20240213143201-ec583247a57a
-	google.golang.org/protobuf v1.33.0
+	google.golang.org/protobuf v1.34.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
 )
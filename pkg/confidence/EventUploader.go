@@ -4,12 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"golang.org/x/exp/slog"
+	"fmt"
+	"log/slog"
 	"net/http"
 )
 
 type EventUploader interface {
-	upload(ctx context.Context, request EventBatchRequest)
+	upload(ctx context.Context, request EventBatchRequest) error
+}
+
+// EventUploaderFunc is an EventUploader calling the function with every batch of tracked events, e.g. to capture the
+// events in tests.
+type EventUploaderFunc func(ctx context.Context, request EventBatchRequest) error
+
+func (f EventUploaderFunc) upload(ctx context.Context, request EventBatchRequest) error {
+	return f(ctx, request)
 }
 
 type HttpEventUploader struct {
@@ -28,26 +37,32 @@ func NewHttpEventUploader(config APIConfig, logger *slog.Logger) HttpEventUpload
 	}
 }
 
-func (e HttpEventUploader) upload(ctx context.Context, request EventBatchRequest) {
+func (e HttpEventUploader) upload(ctx context.Context, request EventBatchRequest) error {
 	jsonRequest, err := json.Marshal(request)
 	if err != nil {
-		return
+		return err
 	}
 
+	baseUrl := e.Config.APIEventsBaseUrl
+	if baseUrl == "" {
+		baseUrl = DefaultAPIEventsBaseUrl
+	}
 	payload := bytes.NewBuffer(jsonRequest)
 	req, err := http.NewRequestWithContext(ctx,
-		http.MethodPost, "https://events.eu.confidence.dev/v1/events:publish", payload)
+		http.MethodPost, fmt.Sprintf("%s/v1/events:publish", baseUrl), payload)
 	if err != nil {
-		return
+		return err
 	}
 
 	resp, err := e.Client.Do(req)
 	if err != nil {
-		e.Logger.Warn("Failed to perform upload request", "error", err)
-		return
+		e.Logger.Warn("Failed to perform upload request", LogKeyError, err)
+		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		e.Logger.Warn("Failed to upload event", "status", resp.Status)
+		e.Logger.Warn("Failed to upload event", LogKeyHTTPStatus, resp.Status)
+		return fmt.Errorf("got '%s' error from the events service", resp.Status)
 	}
-	defer resp.Body.Close()
+	return nil
 }
@@ -2,13 +2,13 @@ package confidence
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
 	"strings"
 	"sync"
 	"time"
-
-	"golang.org/x/exp/slog"
 )
 
 type FlagResolver interface {
@@ -25,13 +25,45 @@ var (
 	SDK_VERSION = "0.4.6" // x-release-please-version
 )
 
-type Confidence struct {
-	parent        ContextProvider
-	EventUploader EventUploader
-	contextMap    map[string]interface{}
+// confidenceCore holds the collaborators shared by a root Confidence and every child created through WithContext.
+// New subsystems belong here so that children can never end up with a partially initialised copy.
+type confidenceCore struct {
 	Config        APIConfig
 	ResolveClient ResolveClient
+	EventUploader EventUploader
 	Logger        *slog.Logger
+	lifecycle     lifecycle
+	status        statusTracker
+	// cache and breaker are nil when disabled in Config.
+	cache   *flagCache
+	breaker *circuitBreaker
+	// overrides is nil when overrides are disabled, see the confidence_overrides build tag.
+	overrides *overrideStore
+	// telemetry is shared with the resolve client when it is an HttpResolveClient, which sends the traces. It is nil
+	// for other clients, nothing would send the traces recorded.
+	telemetry           *telemetryCollector
+	instrumentation     instrumentations
+	evaluationListeners []EvaluationListener
+	// defaultSdk is the SdkInfo of the Confidence SDK, taken from SDK_ID and SDK_VERSION when the core is built.
+	defaultSdk SdkInfo
+}
+
+// errNotBuilt is returned by the methods of a Confidence that wasn't created with ConfidenceBuilder, e.g. a zero
+// value, they don't panic but can't resolve flags or track events.
+var errNotBuilt = errors.New("confidence wasn't built, create it with ConfidenceBuilder")
+
+// Confidence resolves flags and tracks events for an evaluation context. It must be created with ConfidenceBuilder,
+// the collaborators it shares with its children are built by Build.
+type Confidence struct {
+	*confidenceCore
+	parent     ContextProvider
+	contextMap map[string]interface{}
+	// sdkInfo is inherited by children, the zero value stands for the Confidence SDK itself.
+	sdkInfo SdkInfo
+	// logger tags the records of a Confidence bound to a domain with it, the core logger is used if nil.
+	logger *slog.Logger
+	// wrapped is set by WithEvaluationContext, the wrapping library records type mismatches itself.
+	wrapped bool
 }
 
 func (e Confidence) GetContext() map[string]interface{} {
@@ -54,47 +86,116 @@ func (e Confidence) GetContext() map[string]interface{} {
 }
 
 type ConfidenceBuilder struct {
-	confidence Confidence
+	config          APIConfig
+	resolveClient   ResolveClient
+	eventUploader   EventUploader
+	logger          *slog.Logger
+	instrumentation instrumentations
+	listeners       []EvaluationListener
+	overridesFile   string
+	overridesPoll   time.Duration
 }
 
+// SetLogger sets the logger of this Confidence instance and its children. Without one, the handler of slog.Default() is
+// used with the LogKeyInstance attribute, which tells the records of the instances of a process apart.
 func (e ConfidenceBuilder) SetLogger(logger *slog.Logger) ConfidenceBuilder {
-	e.confidence.Logger = logger
+	e.logger = logger
 	return e
 }
 
 func (e ConfidenceBuilder) SetAPIConfig(config APIConfig) ConfidenceBuilder {
-	e.confidence.Config = config
+	e.config = config
 	if config.APIResolveBaseUrl == "" {
-		e.confidence.Config.APIResolveBaseUrl = DefaultAPIResolveBaseUrl
+		e.config.APIResolveBaseUrl = DefaultAPIResolveBaseUrl
+	}
+	if config.APIEventsBaseUrl == "" {
+		e.config.APIEventsBaseUrl = DefaultAPIEventsBaseUrl
 	}
 	return e
 }
 
 func (e ConfidenceBuilder) SetResolveClient(client ResolveClient) ConfidenceBuilder {
-	e.confidence.ResolveClient = client
+	e.resolveClient = client
+	return e
+}
+
+// SetEventUploader replaces the uploader of tracked events, which sends them to the Confidence events service by
+// default.
+func (e ConfidenceBuilder) SetEventUploader(uploader EventUploader) ConfidenceBuilder {
+	e.eventUploader = uploader
+	return e
+}
+
+// AddInstrumentation registers an Instrumentation notified of the work done by the Confidence and its children.
+// Several instrumentations are notified in the order they were added.
+func (e ConfidenceBuilder) AddInstrumentation(instrumentation Instrumentation) ConfidenceBuilder {
+	e.instrumentation = append(append(instrumentations{}, e.instrumentation...), instrumentation)
+	return e
+}
+
+// AddEvaluationListener registers an EvaluationListener notified of the flags resolved by the Confidence and its
+// children, see AuditSink.
+func (e ConfidenceBuilder) AddEvaluationListener(listener EvaluationListener) ConfidenceBuilder {
+	e.listeners = append(append([]EvaluationListener{}, e.listeners...), listener)
+	return e
+}
+
+// SetOverridesFile reads flag overrides from the YAML or JSON file at path, an object of flag references to values
+// like the one of OverridesEnvVar. The file is checked for changes every pollInterval, or every
+// DefaultOverridesPollInterval if it is zero. It is ignored unless overrides are enabled, see SetOverride.
+func (e ConfidenceBuilder) SetOverridesFile(path string, pollInterval time.Duration) ConfidenceBuilder {
+	e.overridesFile = path
+	e.overridesPoll = pollInterval
 	return e
 }
 
 func (e ConfidenceBuilder) Build() Confidence {
-	if e.confidence.Logger == nil {
-		e.confidence.Logger = slog.Default()
+	core := &confidenceCore{
+		Config:              e.config,
+		ResolveClient:       e.resolveClient,
+		EventUploader:       e.eventUploader,
+		Logger:              e.logger,
+		instrumentation:     e.instrumentation,
+		evaluationListeners: e.listeners,
+		defaultSdk:          defaultSdkInfo(),
+	}
+	if core.Logger == nil {
+		core.Logger = slog.Default().With(LogKeyInstance, instanceIds.Add(1))
+	}
+	if core.ResolveClient == nil {
+		core.ResolveClient = NewHttpResolveClient(core.Config)
+	}
+	if client, ok := core.ResolveClient.(*HttpResolveClient); ok {
+		core.telemetry = client.telemetry
+		if client.Logger == nil {
+			client.Logger = core.Logger
+		}
 	}
-	if e.confidence.ResolveClient == nil {
-		e.confidence.ResolveClient = NewHttpResolveClient(e.confidence.Config)
+	for _, instrumentation := range core.instrumentation {
+		if telemetry, ok := instrumentation.(TelemetryInstrumentation); ok {
+			core.telemetry.onDrop(telemetry.TelemetryTraceDropped)
+		}
+	}
+	if core.EventUploader == nil {
+		core.EventUploader = NewHttpEventUploader(core.Config, core.Logger)
+	}
+	if core.Config.CacheTTL > 0 {
+		core.cache = newFlagCache(core.Config.CacheTTL, defaultFlagCacheSize)
 	}
-	if e.confidence.EventUploader == nil {
-		e.confidence.EventUploader = NewHttpEventUploader(e.confidence.Config, e.confidence.Logger)
+	if core.Config.CircuitBreakerThreshold > 0 {
+		core.breaker = newCircuitBreaker(core.Config.CircuitBreakerThreshold, core.Config.CircuitBreakerCooldown)
 	}
+	core.setUpOverrides(e.overridesFile, e.overridesPoll)
 
-	e.confidence.contextMap = make(map[string]interface{})
-	e.confidence.Logger.Info("Confidence created", "config", e.confidence.Config)
-	return e.confidence
+	core.Logger.Info("Confidence created", LogKeyConfig, core.Config)
+	return Confidence{
+		confidenceCore: core,
+		contextMap:     make(map[string]interface{}),
+	}
 }
 
 func NewConfidenceBuilder() ConfidenceBuilder {
-	return ConfidenceBuilder{
-		confidence: Confidence{},
-	}
+	return ConfidenceBuilder{}
 }
 
 func (e Confidence) PutContext(key string, value interface{}) {
@@ -113,8 +214,17 @@ func (e Confidence) Track(ctx context.Context, eventName string, data map[string
 	}
 
 	var wg sync.WaitGroup
+	if !e.built() {
+		e.log().Warn("Event dropped", LogKeyEvent, eventName, LogKeyError, errNotBuilt)
+		return &wg
+	}
+	if !e.lifecycle.begin() {
+		e.log().Warn("Event dropped, Confidence is closed", LogKeyEvent, eventName)
+		return &wg
+	}
 	wg.Add(1)
 	go func() {
+		defer e.lifecycle.end()
 		currentTime := time.Now()
 		iso8601Time := currentTime.Format(time.RFC3339)
 		event := Event{
@@ -124,19 +234,87 @@ func (e Confidence) Track(ctx context.Context, eventName string, data map[string
 		}
 		batch := EventBatchRequest{
 			CclientSecret: e.Config.APIKey,
-			Sdk:           sdk{SDK_ID, SDK_VERSION},
+			Sdk:           sdk{e.sdk().Id, e.sdk().Version},
 			SendTime:      iso8601Time,
 			Events:        []Event{event},
 		}
-		e.Logger.Debug("EventUploading started", "eventName", eventName)
-		e.EventUploader.upload(ctx, batch)
+		e.log().Debug("EventUploading started", LogKeyEvent, eventName)
+		uploadCtx, done := e.instrumentation.StartEventUpload(ctx, batch)
+		done(e.EventUploader.upload(uploadCtx, batch))
 		wg.Done()
-		e.Logger.Debug("EventUploading completed", "eventName", eventName)
+		e.log().Debug("EventUploading completed", LogKeyEvent, eventName)
 	}()
 	return &wg
 }
 
+// OnStatusEvent registers a listener that is called when the state of the Confidence changes, see StatusEventType.
+// Listeners are shared with every child created through WithContext and are called synchronously, so they should
+// return quickly. The returned function unregisters the listener.
+func (e Confidence) OnStatusEvent(listener func(StatusEvent)) func() {
+	if !e.built() {
+		return func() {}
+	}
+	return e.status.addListener(listener)
+}
+
+// RecordTypeMismatch reports an evaluation whose flag type didn't match the requested type through the resolver
+// telemetry. Evaluations made through Confidence are reported already, it is meant for libraries converting resolved
+// values themselves. It has no effect if telemetry is disabled or the resolve client doesn't report telemetry.
+func (e Confidence) RecordTypeMismatch() {
+	if !e.built() {
+		return
+	}
+	e.telemetry.recordCount(e.sdk(), ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH)
+}
+
+// RecordConversion reports a type mismatch introduced when converting the resolved detail to the converted one, e.g.
+// with ToBoolResolutionDetail. Mismatches found while resolving are reported by Confidence already.
+func (e Confidence) RecordConversion(resolved ResolutionDetail, converted ResolutionDetail) {
+	if converted.ErrorCode == TypeMismatchCode && resolved.ErrorCode != TypeMismatchCode {
+		e.RecordTypeMismatch()
+	}
+}
+
+// Flush blocks until all events tracked so far have been uploaded, or until ctx is done.
+func (e Confidence) Flush(ctx context.Context) error {
+	if !e.built() {
+		return nil
+	}
+	return e.lifecycle.wait(ctx)
+}
+
+// Close stops accepting new events and flushes the ones already tracked. Events tracked after Close are dropped.
+// Close applies to the root Confidence and every child created through WithContext, flags can still be resolved.
+func (e Confidence) Close(ctx context.Context) error {
+	if !e.built() {
+		return nil
+	}
+	e.lifecycle.close()
+	e.overrides.close()
+	err := e.Flush(ctx)
+	e.log().Debug("Confidence closed", LogKeyError, err)
+	return err
+}
+
 func (e Confidence) WithContext(context map[string]interface{}) Confidence {
+	if e.built() {
+		e.telemetry.recordCount(e.sdk(), ProtoLibraryTraces_PROTO_TRACE_ID_WITH_CONTEXT)
+	}
+	return e.child(context)
+}
+
+// WithEvaluationContext returns a child like WithContext, without reporting it through the resolver telemetry. It is
+// meant for libraries wrapping Confidence that create a child for every evaluation or event, e.g. the OpenFeature
+// provider, so that only the children created by users are counted. Type mismatches of the evaluations of the child
+// aren't reported either, the library reports them with RecordTypeMismatch once it knows the outcome.
+func (e Confidence) WithEvaluationContext(context map[string]interface{}) Confidence {
+	child := e.child(context)
+	child.wrapped = true
+	return child
+}
+
+// child returns a Confidence sharing the collaborators of e, with the context of e extended with context.
+func (e Confidence) child(context map[string]interface{}) Confidence {
 	newMap := map[string]interface{}{}
 	for key, value := range e.GetContext() {
 		newMap[key] = value
@@ -147,17 +325,88 @@ func (e Confidence) WithContext(context map[string]interface{}) Confidence {
 	}
 
 	return Confidence{
-		parent:        &e,
-		contextMap:    newMap,
-		Config:        e.Config,
-		ResolveClient: e.ResolveClient,
-		Logger:        e.Logger,
+		confidenceCore: e.confidenceCore,
+		parent:         &e,
+		contextMap:     newMap,
+		sdkInfo:        e.sdkInfo,
+		logger:         e.logger,
+		wrapped:        e.wrapped,
+	}
+}
+
+// WithSdkInfo returns a Confidence that shares the context and collaborators of e, and reports its resolves and
+// events as coming from the library described by info. It is meant for libraries wrapping Confidence. The domain of
+// info is added to the log records and to the flag metadata of the evaluations.
+func (e Confidence) WithSdkInfo(info SdkInfo) Confidence {
+	e.sdkInfo = info
+	e.logger = nil
+	if info.Domain != "" {
+		e.logger = e.log().With(LogKeyDomain, info.Domain)
+	}
+	return e
+}
+
+// GetConfig returns the APIConfig of e, or the zero APIConfig if e wasn't built with ConfidenceBuilder.
+func (e Confidence) GetConfig() APIConfig {
+	if !e.built() {
+		return APIConfig{}
 	}
+	return e.Config
+}
+
+// GetLogger returns the logger of e, or slog.Default() if e wasn't built with ConfidenceBuilder.
+func (e Confidence) GetLogger() *slog.Logger {
+	if !e.built() {
+		return slog.Default()
+	}
+	return e.Logger
+}
+
+// built reports whether e was created with ConfidenceBuilder, see errNotBuilt.
+func (e Confidence) built() bool {
+	return e.confidenceCore != nil
+}
+
+func (e Confidence) sdk() SdkInfo {
+	if e.sdkInfo != (SdkInfo{}) {
+		return e.sdkInfo
+	}
+	if !e.built() {
+		return defaultSdkInfo()
+	}
+	return e.defaultSdk
+}
+
+// log returns the logger of e, which includes the domain of its SdkInfo.
+func (e Confidence) log() *slog.Logger {
+	if e.logger != nil {
+		return e.logger
+	}
+	if !e.built() {
+		return slog.Default()
+	}
+	return e.Logger
+}
+
+// withDomain adds the domain of the SdkInfo of e to the flag metadata of detail.
+func (e Confidence) withDomain(detail ResolutionDetail) ResolutionDetail {
+	domain := e.sdk().Domain
+	if domain == "" {
+		return detail
+	}
+	metadata := FlagMetadata{FlagMetadataDomain: domain}
+	for key, value := range detail.FlagMetadata {
+		metadata[key] = value
+	}
+	detail.FlagMetadata = metadata
+	return detail
 }
 
 func (e Confidence) GetBoolFlag(ctx context.Context, flag string, defaultValue bool) BoolResolutionDetail {
 	resp := e.ResolveFlag(ctx, flag, defaultValue, reflect.Bool)
-	return ToBoolResolutionDetail(resp, defaultValue)
+	detail := ToBoolResolutionDetail(resp, defaultValue)
+	e.RecordConversion(resp.ResolutionDetail, detail.ResolutionDetail)
+	return detail
 }
 
 func (e Confidence) GetBoolValue(ctx context.Context, flag string, defaultValue bool) bool {
@@ -166,7 +415,9 @@ func (e Confidence) GetBoolValue(ctx context.Context, flag string, defaultValue
 
 func (e Confidence) GetIntFlag(ctx context.Context, flag string, defaultValue int64) IntResolutionDetail {
 	resp := e.ResolveFlag(ctx, flag, defaultValue, reflect.Int64)
-	return ToIntResolutionDetail(resp, defaultValue)
+	detail := ToIntResolutionDetail(resp, defaultValue)
+	e.RecordConversion(resp.ResolutionDetail, detail.ResolutionDetail)
+	return detail
 }
 
 func (e Confidence) GetIntValue(ctx context.Context, flag string, defaultValue int64) int64 {
@@ -175,7 +426,9 @@ func (e Confidence) GetIntValue(ctx context.Context, flag string, defaultValue i
 
 func (e Confidence) GetDoubleFlag(ctx context.Context, flag string, defaultValue float64) FloatResolutionDetail {
 	resp := e.ResolveFlag(ctx, flag, defaultValue, reflect.Float64)
-	return ToFloatResolutionDetail(resp, defaultValue)
+	detail := ToFloatResolutionDetail(resp, defaultValue)
+	e.RecordConversion(resp.ResolutionDetail, detail.ResolutionDetail)
+	return detail
 }
 
 func (e Confidence) GetDoubleValue(ctx context.Context, flag string, defaultValue float64) float64 {
@@ -184,7 +437,9 @@ func (e Confidence) GetDoubleValue(ctx context.Context, flag string, defaultValu
 
 func (e Confidence) GetStringFlag(ctx context.Context, flag string, defaultValue string) StringResolutionDetail {
 	resp := e.ResolveFlag(ctx, flag, defaultValue, reflect.String)
-	return ToStringResolutionDetail(resp, defaultValue)
+	detail := ToStringResolutionDetail(resp, defaultValue)
+	e.RecordConversion(resp.ResolutionDetail, detail.ResolutionDetail)
+	return detail
 }
 
 func (e Confidence) GetStringValue(ctx context.Context, flag string, defaultValue string) string {
@@ -200,49 +455,292 @@ func (e Confidence) GetObjectValue(ctx context.Context, flag string, defaultValu
 	return e.GetObjectFlag(ctx, flag, defaultValue).Value
 }
 
+func (e Confidence) GetStringListFlag(ctx context.Context, flag string, defaultValue []string) StringListResolutionDetail {
+	return GetFlag(ctx, e, flag, defaultValue)
+}
+
+func (e Confidence) GetStringListValue(ctx context.Context, flag string, defaultValue []string) []string {
+	return e.GetStringListFlag(ctx, flag, defaultValue).Value
+}
+
+func (e Confidence) GetBoolListFlag(ctx context.Context, flag string, defaultValue []bool) BoolListResolutionDetail {
+	return GetFlag(ctx, e, flag, defaultValue)
+}
+
+func (e Confidence) GetBoolListValue(ctx context.Context, flag string, defaultValue []bool) []bool {
+	return e.GetBoolListFlag(ctx, flag, defaultValue).Value
+}
+
+func (e Confidence) GetIntListFlag(ctx context.Context, flag string, defaultValue []int64) IntListResolutionDetail {
+	return GetFlag(ctx, e, flag, defaultValue)
+}
+
+func (e Confidence) GetIntListValue(ctx context.Context, flag string, defaultValue []int64) []int64 {
+	return e.GetIntListFlag(ctx, flag, defaultValue).Value
+}
+
+func (e Confidence) GetDoubleListFlag(ctx context.Context, flag string, defaultValue []float64) FloatListResolutionDetail {
+	return GetFlag(ctx, e, flag, defaultValue)
+}
+
+func (e Confidence) GetDoubleListValue(ctx context.Context, flag string, defaultValue []float64) []float64 {
+	return e.GetDoubleListFlag(ctx, flag, defaultValue).Value
+}
+
 func (e Confidence) ResolveFlag(ctx context.Context, flag string, defaultValue interface{}, expectedKind reflect.Kind) InterfaceResolutionDetail {
-	flagName, propertyPath := splitFlagString(flag)
+	if !e.built() {
+		return InterfaceResolutionDetail{Value: defaultValue, ResolutionDetail: notBuiltDetail()}
+	}
+	startTime := time.Now()
+	ctx, done := e.instrumentation.StartEvaluation(ctx, flag)
+	detail := e.evaluateFlag(ctx, flag, defaultValue, expectedKind)
+	detail.ResolutionDetail = e.withDomain(detail.ResolutionDetail)
+	done(detail.ResolutionDetail)
+	e.logEvaluation(flag, detail.ResolutionDetail, time.Since(startTime))
+	e.notifyEvaluation(flag, detail.ResolutionDetail)
+	return detail
+}
+
+func (e Confidence) evaluateFlag(ctx context.Context, flag string, defaultValue interface{}, expectedKind reflect.Kind) InterfaceResolutionDetail {
+	fetched, failure := e.fetchFlag(ctx, flag, defaultValue)
+	if failure != nil {
+		return *failure
+	}
+
+	detail := processResolvedFlag(fetched.resolvedFlag, defaultValue, expectedKind, fetched.path)
+	if detail.ErrorCode == TypeMismatchCode && !e.wrapped {
+		e.RecordTypeMismatch()
+	}
+	detail.Reason = fetched.reason(detail.Reason)
+	detail.FlagMetadata = fetched.flagMetadata()
+	return detail
+}
+
+// fetchedFlag is a flag resolved for a single evaluation, together with the requested property path.
+type fetchedFlag struct {
+	resolvedFlag
+	path     propertyPath
+	source   ResolveSource
+	metadata FlagMetadata
+}
+
+// reason returns the reason to report for the evaluation, matches served from the cache are reported as cached and
+// overridden values as static.
+func (f fetchedFlag) reason(reason Reason) Reason {
+	if reason == TargetingMatchReason && f.source == ResolveSourceOverride {
+		return StaticReason
+	}
+	if reason == TargetingMatchReason && f.source == ResolveSourceCache {
+		return CachedReason
+	}
+	return reason
+}
+
+// flagMetadata returns the metadata of the evaluation, including the schema type of the requested property.
+func (f fetchedFlag) flagMetadata() FlagMetadata {
+	metadata := FlagMetadata{}
+	for key, value := range f.metadata {
+		metadata[key] = value
+	}
+	if f.Reason != "" {
+		metadata[FlagMetadataResolveReason] = f.Reason
+	}
+	if f.Reason == resolveReasonFlagArchived {
+		metadata[FlagMetadataArchived] = true
+	}
+	if kind, err := getTypeForPath(f.FlagSchema.Schema, f.path); err == nil {
+		metadata[FlagMetadataSchemaType] = schemaTypeName(kind)
+	}
+	return metadata
+}
+
+// fetchFlag resolves the flag referenced by flag and returns it together with the requested property path.
+// If the flag can't be resolved, the returned detail holds defaultValue and the reason for the failure.
+func (e Confidence) fetchFlag(ctx context.Context, flag string, defaultValue interface{}) (fetchedFlag, *InterfaceResolutionDetail) {
+	flagName, propertyPath, parseErr := parseFlagPath(flag)
+	if parseErr != nil {
+		err := NewParseErrorResolutionError(parseErr.Error())
+		return fetchedFlag{}, &InterfaceResolutionDetail{
+			Value: defaultValue,
+			ResolutionDetail: ResolutionDetail{
+				Variant:      "",
+				Reason:       ErrorReason,
+				ErrorCode:    err.code,
+				ErrorMessage: err.message,
+				FlagMetadata: nil,
+			},
+		}
+	}
 
 	requestFlagName := fmt.Sprintf("flags/%s", flagName)
-	resp, err := e.ResolveClient.SendResolveRequest(ctx,
-		ResolveRequest{ClientSecret: e.Config.APIKey,
-			Flags: []string{requestFlagName}, Apply: true, EvaluationContext: e.contextMap,
-			Sdk: sdk{Id: SDK_ID, Version: SDK_VERSION}})
+	overrides, overridden := e.overrides.lookup(flagName, propertyPath)
+	if overridden {
+		return fetchedFlag{
+			resolvedFlag: applyOverrides(resolvedFlag{Flag: requestFlagName, Variant: "override"}, overrides),
+			path:         propertyPath,
+			source:       ResolveSourceOverride,
+			metadata: FlagMetadata{
+				FlagMetadataFlag:     requestFlagName,
+				FlagMetadataSource:   string(ResolveSourceOverride),
+				FlagMetadataOverride: overrides[len(overrides)-1].origin,
+			},
+		}, nil
+	}
+	startTime := time.Now()
+	resp, err := e.resolve(ctx, requestFlagName)
+	metadata := FlagMetadata{
+		FlagMetadataFlag:             requestFlagName,
+		FlagMetadataResolveLatencyMs: time.Since(startTime).Milliseconds(),
+		FlagMetadataSource:           string(resp.source()),
+	}
 
 	if err != nil {
-		slog.Warn("Error in resolving flag", "flag", flag, "error", err)
-		return processResolveError(err, defaultValue)
+		e.log().Warn("Error in resolving flag", LogKeyFlag, flag, LogKeyError, err)
+		failure := processResolveError(err, defaultValue)
+		failure.FlagMetadata = metadata
+		return fetchedFlag{}, &failure
+	}
+	if e.Config.EnableResolveTesterHint {
+		logResolveTesterHint(e.log(), flagName, e.Config.APIKey, e.Config.Redaction.Apply(e.contextMap))
 	}
-	logResolveTesterHint(e.Logger, flagName, e.Config.APIKey, e.contextMap)
+	metadata[FlagMetadataResolveToken] = resp.ResolveToken
 
 	if len(resp.ResolvedFlags) == 0 {
-		slog.Debug("Flag not found", "flag", flag)
-		return InterfaceResolutionDetail{
+		e.log().Debug("Flag not found", LogKeyFlag, flag)
+		return fetchedFlag{}, &InterfaceResolutionDetail{
 			Value: defaultValue,
 			ResolutionDetail: ResolutionDetail{
 				Variant:      "",
 				Reason:       ErrorReason,
 				ErrorCode:    FlagNotFoundCode,
 				ErrorMessage: "Flag not found",
-				FlagMetadata: nil,
+				FlagMetadata: metadata,
 			},
 		}
 	}
 
-	resolvedFlag := resp.ResolvedFlags[0]
-	if resolvedFlag.Flag != requestFlagName {
-		slog.Warn("Unexpected flag from remote", "flag", resolvedFlag.Flag)
-		return InterfaceResolutionDetail{
+	resolved := resp.ResolvedFlags[0]
+	if resolved.Flag != requestFlagName {
+		e.log().Warn("Unexpected flag from remote", LogKeyFlag, resolved.Flag)
+		return fetchedFlag{}, &InterfaceResolutionDetail{
 			Value: defaultValue,
 			ResolutionDetail: ResolutionDetail{
 				Variant:      "",
 				Reason:       ErrorReason,
 				ErrorCode:    FlagNotFoundCode,
-				ErrorMessage: fmt.Sprintf("unexpected flag '%s' from remote", strings.TrimPrefix(resolvedFlag.Flag, "flags/")),
-				FlagMetadata: nil,
+				ErrorMessage: fmt.Sprintf("unexpected flag '%s' from remote", strings.TrimPrefix(resolved.Flag, "flags/")),
+				FlagMetadata: metadata,
 			},
 		}
 	}
 
-	return processResolvedFlag(resolvedFlag, defaultValue, expectedKind, propertyPath)
+	if len(overrides) > 0 {
+		// Properties within the requested one are overridden, the rest of it is resolved.
+		resolved = applyOverrides(resolved, overrides)
+		metadata[FlagMetadataOverride] = overrides[len(overrides)-1].origin
+	}
+	return fetchedFlag{resolvedFlag: resolved, path: propertyPath, source: resp.source(), metadata: metadata}, nil
+}
+
+// resolve resolves flag for the current context, going through the flag cache and the circuit breaker when enabled.
+// Cached flags past their TTL are refreshed, and served as stale if the resolver can't be reached.
+func (e Confidence) resolve(ctx context.Context, flag string) (ResolveResponse, error) {
+	var cacheKey string
+	var cacheable, hasCached bool
+	var cached ResolveResponse
+	if e.cache != nil {
+		cacheKey, cacheable = flagCacheKey(flag, e.contextMap)
+	}
+	if cacheable {
+		response, fresh, found := e.cache.get(cacheKey)
+		if fresh {
+			e.telemetry.recordRequest(e.sdk(), 0, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_CACHED)
+			response.Source = ResolveSourceCache
+			return response, nil
+		}
+		cached, hasCached = response, found
+	}
+
+	if e.breaker != nil && !e.breaker.allow() {
+		return e.serveStale(flag, cached, hasCached, errCircuitOpen)
+	}
+
+	request := ResolveRequest{ClientSecret: e.Config.APIKey,
+		Flags: []string{flag}, Apply: true, EvaluationContext: e.contextMap,
+		Sdk: sdk{Id: e.sdk().Id, Version: e.sdk().Version}, library: e.sdk().Library}
+	requestCtx, done := e.instrumentation.StartResolveRequest(ctx, request)
+	resp, err := e.ResolveClient.SendResolveRequest(requestCtx, request)
+	done(resp, err)
+	if err != nil {
+		if e.breaker != nil && !errors.Is(err, errFlagNotFound) && e.breaker.failure() {
+			e.log().Warn("Opening the circuit to the resolver", LogKeyError, err)
+			e.status.transition(StatusError, errCircuitOpen.Error())
+		}
+		return e.serveStale(flag, cached, hasCached, err)
+	}
+
+	if e.breaker != nil {
+		e.breaker.success()
+	}
+	e.status.transition(StatusReady, "flags resolved")
+	if cacheable {
+		if hasCached && flagsChanged(cached, resp) {
+			e.status.configurationChanged([]string{strings.TrimPrefix(flag, "flags/")})
+		}
+		e.cache.put(cacheKey, resp)
+	}
+	return resp, nil
+}
+
+// WarmUp resolves every flag for the current context without applying them, to check that the resolver can be
+// reached and to open the connection to it before the first evaluation. When the flag cache is enabled the resolved
+// flags are stored in it, they are still resolved and applied on their first evaluation but can be served stale if
+// the resolver can't be reached. It returns the error of the resolve, if any.
+func (e Confidence) WarmUp(ctx context.Context) error {
+	if !e.built() {
+		return errNotBuilt
+	}
+	request := ResolveRequest{ClientSecret: e.Config.APIKey,
+		Flags: []string{}, Apply: false, EvaluationContext: e.contextMap,
+		Sdk: sdk{Id: e.sdk().Id, Version: e.sdk().Version}, library: e.sdk().Library}
+	requestCtx, done := e.instrumentation.StartResolveRequest(ctx, request)
+	resp, err := e.ResolveClient.SendResolveRequest(requestCtx, request)
+	done(resp, err)
+	if err != nil {
+		e.log().Warn("Failed to warm up", LogKeyError, err)
+		return err
+	}
+	e.status.transition(StatusReady, "flags resolved")
+	if e.cache != nil {
+		for _, flag := range resp.ResolvedFlags {
+			if cacheKey, cacheable := flagCacheKey(flag.Flag, e.contextMap); cacheable {
+				e.cache.putUnapplied(cacheKey,
+					ResolveResponse{ResolvedFlags: []resolvedFlag{flag}, ResolveToken: resp.ResolveToken})
+			}
+		}
+	}
+	return nil
+}
+
+// notBuiltDetail is the detail of the evaluations of a Confidence that wasn't built, see errNotBuilt.
+func notBuiltDetail() ResolutionDetail {
+	return ResolutionDetail{
+		Reason:       ErrorReason,
+		ErrorCode:    ProviderNotReadyCode,
+		ErrorMessage: errNotBuilt.Error(),
+	}
+}
+
+// serveStale returns the cached response past its TTL if there is one, and err otherwise.
+func (e Confidence) serveStale(flag string, cached ResolveResponse, found bool, err error) (ResolveResponse, error) {
+	if !found {
+		return ResolveResponse{}, err
+	}
+	e.log().Debug("Serving stale flag from cache", LogKeyFlag, flag, LogKeyError, err)
+	e.telemetry.recordCount(e.sdk(), ProtoLibraryTraces_PROTO_TRACE_ID_STALE_FLAG)
+	if e.breaker == nil || !e.breaker.isOpen() {
+		e.status.transition(StatusStale, "serving cached flags past their TTL")
+	}
+	cached.Source = ResolveSourceCache
+	return cached, nil
 }
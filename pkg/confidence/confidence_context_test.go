@@ -2,10 +2,10 @@ package confidence
 
 import (
 	"context"
+	"log/slog"
 	"reflect"
 	"testing"
-
-	"golang.org/x/exp/slog"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,9 +26,10 @@ func didPanic(f func()) (didPanic bool) {
 	return
 }
 
-func (e MockEventUploader) upload(ctx context.Context, request EventBatchRequest) {
+func (e MockEventUploader) upload(ctx context.Context, request EventBatchRequest) error {
 	event := request.Events[0]
 	assert.True(e.TestingT, reflect.DeepEqual(e.expectedContext, event.Payload["context"]))
+	return nil
 }
 
 func TestContextExistsInPayload(t *testing.T) {
@@ -96,27 +97,95 @@ func TestChildContextRemoveParentContext(t *testing.T) {
 	assert.Equal(t, child.GetContext(), map[string]interface{}{})
 }
 
+func TestChildTrackUsesParentUploader(t *testing.T) {
+	eventUploader := MockEventUploader{
+		expectedContext: map[string]interface{}{"hello": "hey", "west": "world"},
+		TestingT:        t,
+	}
+	client := createConfidenceWithUploader(t, templateResponse(), eventUploader)
+	client.PutContext("hello", "hey")
+	child := client.WithContext(map[string]interface{}{"west": "world"})
+	assert.NotPanics(t, func() {
+		wg := child.Track(context.Background(), "test", map[string]interface{}{})
+		wg.Wait()
+	})
+}
+
+func TestChildSharesCoreWithParent(t *testing.T) {
+	client := create_confidence(t, templateResponse())
+	child := client.WithContext(map[string]interface{}{"west": "world"})
+	grandChild := child.WithContext(map[string]interface{}{"hello": "hey"})
+	assert.Same(t, client.confidenceCore, child.confidenceCore)
+	assert.Same(t, client.confidenceCore, grandChild.confidenceCore)
+}
+
 func create_confidence(t *testing.T, response ResolveResponse) *Confidence {
 	config := APIConfig{
 		APIKey: "apiKey",
 	}
 	return &Confidence{
-		Config:        config,
-		ResolveClient: MockResolveClient{MockedResponse: response, MockedError: nil, TestingT: t},
-		contextMap:    make(map[string]interface{}),
-		Logger:        slog.Default(),
+		confidenceCore: &confidenceCore{
+			Config:        config,
+			ResolveClient: MockResolveClient{MockedResponse: response, MockedError: nil, TestingT: t},
+			Logger:        slog.Default(),
+		},
+		contextMap: make(map[string]interface{}),
 	}
 }
 
+type blockingEventUploader struct {
+	release  chan struct{}
+	uploaded chan string
+}
+
+func (e blockingEventUploader) upload(ctx context.Context, request EventBatchRequest) error {
+	<-e.release
+	e.uploaded <- request.Events[0].EventDefinition
+	return nil
+}
+
+func TestFlushWaitsForPendingEvents(t *testing.T) {
+	uploader := blockingEventUploader{release: make(chan struct{}), uploaded: make(chan string, 1)}
+	client := create_confidence(t, templateResponse())
+	client.EventUploader = uploader
+	client.Track(context.Background(), "test", map[string]interface{}{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.Flush(ctx), context.DeadlineExceeded)
+
+	close(uploader.release)
+	assert.NoError(t, client.Flush(context.Background()))
+	assert.Equal(t, "eventDefinitions/test", <-uploader.uploaded)
+}
+
+func TestCloseFlushesAndDropsLaterEvents(t *testing.T) {
+	uploader := blockingEventUploader{release: make(chan struct{}), uploaded: make(chan string, 2)}
+	client := create_confidence(t, templateResponse())
+	client.EventUploader = uploader
+	child := client.WithContext(map[string]interface{}{"west": "world"})
+	child.Track(context.Background(), "before-close", map[string]interface{}{})
+	close(uploader.release)
+
+	assert.NoError(t, client.Close(context.Background()))
+	assert.Equal(t, "eventDefinitions/before-close", <-uploader.uploaded)
+
+	child.Track(context.Background(), "after-close", map[string]interface{}{}).Wait()
+	assert.NoError(t, child.Flush(context.Background()))
+	assert.Empty(t, uploader.uploaded)
+}
+
 func createConfidenceWithUploader(t *testing.T, response ResolveResponse, uploader MockEventUploader) *Confidence {
 	config := APIConfig{
 		APIKey: "apiKey",
 	}
 	return &Confidence{
-		Config:        config,
-		EventUploader: uploader,
-		ResolveClient: MockResolveClient{MockedResponse: response, MockedError: nil, TestingT: t},
-		contextMap:    make(map[string]interface{}),
-		Logger:        slog.Default(),
+		confidenceCore: &confidenceCore{
+			Config:        config,
+			EventUploader: uploader,
+			ResolveClient: MockResolveClient{MockedResponse: response, MockedError: nil, TestingT: t},
+			Logger:        slog.Default(),
+		},
+		contextMap: make(map[string]interface{}),
 	}
 }
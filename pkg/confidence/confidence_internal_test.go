@@ -4,12 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"strings"
 	"testing"
 
-	"golang.org/x/exp/slog"
-
 	"github.com/stretchr/testify/assert"
 )
 
@@ -302,11 +303,233 @@ func TestResolveWithNonExistingFlag(t *testing.T) {
 	assert.Equal(t, "Flag not found", evalDetails.ErrorMessage)
 }
 
+func TestResolveStringList(t *testing.T) {
+	client := client(t, listResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	evalDetails := client.GetStringListFlag(context.Background(), "list-flag.tags", []string{"default"})
+
+	assert.Equal(t, []string{"a", "b"}, evalDetails.Value)
+	assert.Equal(t, TargetingMatchReason, evalDetails.Reason)
+}
+
+func TestResolveIntListConvertsNumbers(t *testing.T) {
+	client := client(t, listResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	evalDetails := client.GetIntListFlag(context.Background(), "list-flag.numbers", nil)
+	assert.Equal(t, []int64{1, 2, 3}, evalDetails.Value)
+
+	objectDetails := client.GetObjectFlag(context.Background(), "list-flag", map[string]interface{}{})
+	value, _ := objectDetails.Value.(map[string]interface{})
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, value["numbers"])
+}
+
+func TestResolveListWithWrongType(t *testing.T) {
+	client := client(t, listResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	evalDetails := client.GetBoolListFlag(context.Background(), "list-flag.tags", []bool{true})
+
+	assert.Equal(t, []bool{true}, evalDetails.Value)
+	assert.Equal(t, ErrorReason, evalDetails.Reason)
+	assert.Equal(t, TypeMismatchCode, evalDetails.ErrorCode)
+}
+
+func TestResolveListElementByIndex(t *testing.T) {
+	client := client(t, listResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	assert.Equal(t, "second", client.GetStringValue(context.Background(), "list-flag.items[1].name", "default"))
+	assert.Equal(t, 2.5, client.GetDoubleValue(context.Background(), "list-flag.items[1].weight", 0))
+	assert.Equal(t, int64(3), client.GetIntValue(context.Background(), "list-flag.numbers[2]", 0))
+
+	outOfRange := client.GetStringFlag(context.Background(), "list-flag.items[5].name", "default")
+	assert.Equal(t, "default", outOfRange.Value)
+	assert.Equal(t, TypeMismatchCode, outOfRange.ErrorCode)
+}
+
+func TestResolveWithJSONPointer(t *testing.T) {
+	client := client(t, listResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	assert.Equal(t, "second", client.GetStringValue(context.Background(), "list-flag/items/1/name", "default"))
+}
+
+func TestResolveWithMalformedPath(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	evalDetails := client.GetStringFlag(context.Background(), "test-flag.struct-key[", "default")
+
+	assert.Equal(t, "default", evalDetails.Value)
+	assert.Equal(t, ErrorReason, evalDetails.Reason)
+	assert.Equal(t, ParseErrorCode, evalDetails.ErrorCode)
+}
+
+func TestResolveFlagMetadata(t *testing.T) {
+	response := templateResponse()
+	response.ResolveToken = "token1"
+	client := client(t, response, nil)
+	client.PutContext("targeting_key", "user1")
+
+	evalDetails := client.GetIntFlag(context.Background(), "test-flag.struct-key.integer-key", 99)
+
+	assert.Equal(t, "flags/test-flag", evalDetails.FlagMetadata[FlagMetadataFlag])
+	assert.Equal(t, "token1", evalDetails.FlagMetadata[FlagMetadataResolveToken])
+	assert.Equal(t, "int", evalDetails.FlagMetadata[FlagMetadataSchemaType])
+	assert.Equal(t, "network", evalDetails.FlagMetadata[FlagMetadataSource])
+	assert.IsType(t, int64(0), evalDetails.FlagMetadata[FlagMetadataResolveLatencyMs])
+}
+
+func TestResolveFlagMetadataOnTypeMismatch(t *testing.T) {
+	response := templateResponse()
+	response.Source = ResolveSourceLocal
+	client := client(t, response, nil)
+	client.PutContext("targeting_key", "user1")
+
+	evalDetails := client.GetBoolFlag(context.Background(), "test-flag.string-key", false)
+
+	assert.Equal(t, TypeMismatchCode, evalDetails.ErrorCode)
+	assert.Equal(t, "string", evalDetails.FlagMetadata[FlagMetadataSchemaType])
+	assert.Equal(t, "local", evalDetails.FlagMetadata[FlagMetadataSource])
+}
+
+func TestResolveFlagMetadataOnError(t *testing.T) {
+	client := client(t, templateResponse(), errors.New("boom"))
+	client.PutContext("targeting_key", "user1")
+
+	evalDetails := client.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+
+	assert.Equal(t, GeneralCode, evalDetails.ErrorCode)
+	assert.Equal(t, "flags/test-flag", evalDetails.FlagMetadata[FlagMetadataFlag])
+	assert.NotContains(t, evalDetails.FlagMetadata, FlagMetadataSchemaType)
+}
+
 func client(t *testing.T, response ResolveResponse, errorToReturn error) *Confidence {
 	confidence := newConfidence("apiKey", MockResolveClient{MockedResponse: response, MockedError: errorToReturn, TestingT: t})
 	return confidence
 }
 
+func TestInstancesLogToTheirOwnLogger(t *testing.T) {
+	var first, second bytes.Buffer
+	failing := MockResolveClient{MockedError: errors.New("unavailable"), TestingT: t}
+	firstConfidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "first"}).SetResolveClient(failing).
+		SetLogger(slog.New(slog.NewTextHandler(&first, nil))).Build()
+	secondConfidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "second"}).SetResolveClient(failing).
+		SetLogger(slog.New(slog.NewTextHandler(&second, nil))).Build()
+	firstConfidence.PutContext("targeting_key", "user1")
+
+	firstConfidence.GetBoolValue(context.Background(), "test-flag.boolean-key", true)
+
+	assert.Contains(t, first.String(), "Error in resolving flag")
+	assert.NotContains(t, second.String(), "Error in resolving flag")
+	assert.Equal(t, "second", secondConfidence.Config.APIKey)
+}
+
+func TestInstancesWithoutLoggerAreTaggedApart(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	first := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "first"}).Build()
+	second := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "second"}).Build()
+
+	assert.NotSame(t, first.Logger, second.Logger)
+	records := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	assert.Len(t, records, 2)
+	assert.NotEqual(t, instanceOf(records[0]), instanceOf(records[1]))
+}
+
+// instanceOf returns the LogKeyInstance attribute of a text log record.
+func instanceOf(record string) string {
+	_, instance, _ := strings.Cut(record, LogKeyInstance+"=")
+	return instance
+}
+
+func TestDomainTagsLogsAndFlagMetadata(t *testing.T) {
+	var logs bytes.Buffer
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).
+		SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))).Build()
+	confidence.PutContext("targeting_key", "user1")
+	bound := confidence.WithSdkInfo(SdkInfo{Id: "SDK_ID_GO_PROVIDER", Version: "1.2.3", Domain: "search"})
+
+	detail := bound.WithContext(nil).GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+	unbound := confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+
+	assert.Equal(t, "search", detail.FlagMetadata[FlagMetadataDomain])
+	assert.NotContains(t, unbound.FlagMetadata, FlagMetadataDomain)
+	assert.Contains(t, logs.String(), "domain=search")
+}
+
+func TestSdkInfoIsTakenWhenBuilt(t *testing.T) {
+	version := SDK_VERSION
+	defer func() { SDK_VERSION = version }()
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).Build()
+
+	SDK_VERSION = "changed"
+
+	assert.Equal(t, version, confidence.sdk().Version)
+}
+
+func TestStatusListenersCanBeRemoved(t *testing.T) {
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).Build()
+	var kept, removed []StatusEvent
+	confidence.OnStatusEvent(func(event StatusEvent) { kept = append(kept, event) })
+	unsubscribe := confidence.OnStatusEvent(func(event StatusEvent) { removed = append(removed, event) })
+
+	unsubscribe()
+	confidence.status.transition(StatusReady, "flags resolved")
+
+	assert.Len(t, kept, 1)
+	assert.Empty(t, removed)
+}
+
+func TestArchivedFlagIsDisabledWithArchivedMetadata(t *testing.T) {
+	archived := templateResponse()
+	archived.ResolvedFlags[0].Reason = "RESOLVE_REASON_FLAG_ARCHIVED"
+	archived.ResolvedFlags[0].Value = map[string]interface{}{}
+	unmatched := templateResponse()
+	unmatched.ResolvedFlags[0].Reason = "RESOLVE_REASON_NO_SEGMENT_MATCH"
+	unmatched.ResolvedFlags[0].Value = map[string]interface{}{}
+
+	archivedConfidence, unmatchedConfidence := client(t, archived, nil), client(t, unmatched, nil)
+	archivedConfidence.PutContext("targeting_key", "user1")
+	unmatchedConfidence.PutContext("targeting_key", "user1")
+
+	detail := archivedConfidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", true)
+	unmatchedDetail := unmatchedConfidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", true)
+
+	assert.Equal(t, DisabledReason, detail.Reason)
+	assert.Equal(t, true, detail.FlagMetadata[FlagMetadataArchived])
+	assert.NotContains(t, unmatchedDetail.FlagMetadata, FlagMetadataArchived)
+}
+
+func TestZeroConfidenceDoesNotPanic(t *testing.T) {
+	var confidence Confidence
+
+	detail := confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", true)
+	var target struct{}
+	decoded := confidence.DecodeFlag(context.Background(), "test-flag", &target)
+	child := confidence.WithContext(map[string]interface{}{"targeting_key": "user1"})
+	child.Track(context.Background(), "event", nil).Wait()
+	confidence.RecordTypeMismatch()
+	confidence.OnStatusEvent(func(StatusEvent) {})()
+
+	assert.True(t, detail.Value)
+	assert.Equal(t, ProviderNotReadyCode, detail.ErrorCode)
+	assert.Equal(t, ProviderNotReadyCode, decoded.ErrorCode)
+	assert.Equal(t, errNotBuilt, confidence.WarmUp(context.Background()))
+	assert.Equal(t, errNotBuilt, confidence.SetOverride("test-flag", true))
+	assert.NoError(t, confidence.Close(context.Background()))
+	assert.Equal(t, APIConfig{}, confidence.GetConfig())
+	assert.Equal(t, slog.Default(), confidence.GetLogger())
+}
+
 func templateResponse() ResolveResponse {
 	return templateResponseWithFlagName("test-flag")
 }
@@ -425,6 +648,56 @@ func templateResponseWithFlagName(flagName string) ResolveResponse {
 	return result
 }
 
+func listResponse() ResolveResponse {
+	templateResolveResponse := `
+{
+    "resolvedFlags": [
+        {
+            "flag": "flags/list-flag",
+            "variant": "flags/list-flag/variants/treatment",
+            "value": {
+                "tags": ["a", "b"],
+                "numbers": [1, 2, 3],
+                "items": [
+                    {"name": "first", "weight": 1.5},
+                    {"name": "second", "weight": 2.5}
+                ]
+            },
+            "flagSchema": {
+                "schema": {
+                    "tags": {
+                        "listSchema": {"elementSchema": {"stringSchema": {}}}
+                    },
+                    "numbers": {
+                        "listSchema": {"elementSchema": {"intSchema": {}}}
+                    },
+                    "items": {
+                        "listSchema": {
+                            "elementSchema": {
+                                "structSchema": {
+                                    "schema": {
+                                        "name": {"stringSchema": {}},
+                                        "weight": {"doubleSchema": {}}
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "reason": "RESOLVE_REASON_MATCH"
+        }
+    ],
+    "resolveToken": ""
+}
+`
+	var result ResolveResponse
+	decoder := json.NewDecoder(bytes.NewBuffer([]byte(templateResolveResponse)))
+	decoder.UseNumber()
+	_ = decoder.Decode(&result)
+	return result
+}
+
 func emptyResponse() ResolveResponse {
 	templateResolveResponse :=
 		`
@@ -445,9 +718,11 @@ func newConfidence(apiKey string, client ResolveClient) *Confidence {
 		APIKey: apiKey,
 	}
 	return &Confidence{
-		Config:        config,
-		ResolveClient: client,
-		contextMap:    make(map[string]interface{}),
-		Logger:        slog.Default(),
+		confidenceCore: &confidenceCore{
+			Config:        config,
+			ResolveClient: client,
+			Logger:        slog.Default(),
+		},
+		contextMap: make(map[string]interface{}),
 	}
 }
@@ -0,0 +1,339 @@
+package confidence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// TypedResolutionDetail provides a resolution detail with a value of type T
+type TypedResolutionDetail[T any] struct {
+	Value T
+	ResolutionDetail
+}
+
+// Get resolves flag and decodes its value into T, see DecodeFlag for the decoding rules.
+// defaultValue is returned if the flag can't be resolved or its schema doesn't match T.
+func Get[T any](ctx context.Context, confidence Confidence, flag string, defaultValue T) T {
+	return GetFlag(ctx, confidence, flag, defaultValue).Value
+}
+
+// GetFlag is like Get but also returns the details of the resolution.
+func GetFlag[T any](ctx context.Context, confidence Confidence, flag string, defaultValue T) TypedResolutionDetail[T] {
+	value := defaultValue
+	detail := confidence.DecodeFlag(ctx, flag, &value)
+	return TypedResolutionDetail[T]{
+		Value:            value,
+		ResolutionDetail: detail,
+	}
+}
+
+// DecodeFlag resolves flag and decodes its value into target, which must be a non-nil pointer.
+//
+// Struct fields are matched to flag properties by their `confidence` tag, falling back to the field name, and fields
+// tagged with "-" are skipped. Every decoded field must be declared in the flag schema with a compatible type.
+// target is only written when the whole value matches, otherwise the returned detail carries TypeMismatchCode.
+// Properties resolved to null leave the corresponding part of target untouched.
+func (e Confidence) DecodeFlag(ctx context.Context, flag string, target interface{}) ResolutionDetail {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Pointer || targetValue.IsNil() {
+		err := NewGeneralResolutionError("decode target must be a non-nil pointer")
+		return ResolutionDetail{
+			Variant:      "",
+			Reason:       ErrorReason,
+			ErrorCode:    err.code,
+			ErrorMessage: err.message,
+			FlagMetadata: nil,
+		}
+	}
+	if !e.built() {
+		return notBuiltDetail()
+	}
+
+	startTime := time.Now()
+	ctx, done := e.instrumentation.StartEvaluation(ctx, flag)
+	detail := e.withDomain(e.decodeFlag(ctx, flag, targetValue))
+	done(detail)
+	e.logEvaluation(flag, detail, time.Since(startTime))
+	e.notifyEvaluation(flag, detail)
+	return detail
+}
+
+func (e Confidence) decodeFlag(ctx context.Context, flag string, targetValue reflect.Value) ResolutionDetail {
+	fetched, failure := e.fetchFlag(ctx, flag, nil)
+	if failure != nil {
+		return failure.ResolutionDetail
+	}
+
+	detail := decodeResolvedFlag(fetched.resolvedFlag, fetched.path, targetValue.Elem())
+	if detail.ErrorCode == TypeMismatchCode {
+		e.RecordTypeMismatch()
+	}
+	detail.Reason = fetched.reason(detail.Reason)
+	detail.FlagMetadata = fetched.flagMetadata()
+	return detail
+}
+
+func decodeResolvedFlag(resolvedFlag resolvedFlag, propertyPath propertyPath, target reflect.Value) ResolutionDetail {
+	if detail, handled := processResolverReason(resolvedFlag.Reason, nil); handled {
+		return detail.ResolutionDetail
+	}
+
+	if len(resolvedFlag.Value) == 0 {
+		return ResolutionDetail{
+			Reason: DefaultReason}
+	}
+
+	propertySchema, schemaErr := getSchemaForPath(resolvedFlag.FlagSchema.Schema, propertyPath)
+	if schemaErr != nil {
+		return typeMismatchDetail(fmt.Sprintf("schema for property %s does not match the expected type: %s",
+			propertyPath, schemaErr))
+	}
+
+	updatedMap, err := replaceNumbers(resolvedFlag.Value, resolvedFlag.FlagSchema.Schema)
+	if err != nil {
+		return typeMismatchError(nil).ResolutionDetail
+	}
+
+	extractedValue, extractValueError := extractPropertyValue(propertyPath, updatedMap)
+	if extractValueError != nil {
+		return typeMismatchError(nil).ResolutionDetail
+	}
+
+	// Decode into a copy so that target is never left partially written.
+	decoded := reflect.New(target.Type()).Elem()
+	decoded.Set(target)
+	if err := decodeValue(extractedValue, propertySchema, decoded, propertyPath); err != nil {
+		return typeMismatchDetail(err.Error())
+	}
+	target.Set(decoded)
+
+	return ResolutionDetail{
+		Reason:  TargetingMatchReason,
+		Variant: resolvedFlag.Variant}
+}
+
+func typeMismatchDetail(message string) ResolutionDetail {
+	err := NewTypeMismatchResolutionError(message)
+	return ResolutionDetail{
+		Variant:      "",
+		Reason:       ErrorReason,
+		ErrorCode:    err.code,
+		ErrorMessage: err.message,
+		FlagMetadata: nil,
+	}
+}
+
+// decodeValue writes value into target after checking that target's type is compatible with propertySchema.
+func decodeValue(value interface{}, propertySchema map[string]interface{}, target reflect.Value, path propertyPath) error {
+	kind, err := schemaKind(propertySchema, path)
+	if err != nil {
+		return err
+	}
+
+	switch target.Kind() {
+	case reflect.Interface:
+		if target.NumMethod() != 0 {
+			return fmt.Errorf("unable to decode %s into %s", path.description(), target.Type())
+		}
+		if value != nil {
+			target.Set(reflect.ValueOf(value))
+		}
+		return nil
+	case reflect.Pointer:
+		if value == nil {
+			return decodeValue(nil, propertySchema, reflect.New(target.Type().Elem()).Elem(), path)
+		}
+		elem := reflect.New(target.Type().Elem())
+		if !target.IsNil() {
+			elem.Elem().Set(target.Elem())
+		}
+		if err := decodeValue(value, propertySchema, elem.Elem(), path); err != nil {
+			return err
+		}
+		target.Set(elem)
+		return nil
+	}
+
+	if expected := targetSchemaKind(target.Type()); expected != kind {
+		return fmt.Errorf("schema for %s does not match %s", path.description(), target.Type())
+	}
+	if value == nil {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Bool:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("%s is not a boolean", path.description())
+		}
+		target.SetBool(v)
+	case reflect.String:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s is not a string", path.description())
+		}
+		target.SetString(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, ok := toInt64(value)
+		if !ok || target.OverflowInt(v) {
+			return fmt.Errorf("%s does not fit in %s", path.description(), target.Type())
+		}
+		target.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, ok := toInt64(value)
+		if !ok || v < 0 || target.OverflowUint(uint64(v)) {
+			return fmt.Errorf("%s does not fit in %s", path.description(), target.Type())
+		}
+		target.SetUint(uint64(v))
+	case reflect.Float32, reflect.Float64:
+		v, ok := toFloat64(value)
+		if !ok || target.OverflowFloat(v) {
+			return fmt.Errorf("%s does not fit in %s", path.description(), target.Type())
+		}
+		target.SetFloat(v)
+	case reflect.Struct:
+		return decodeStruct(value, structFields(propertySchema), target, path)
+	case reflect.Map:
+		return decodeMap(value, structFields(propertySchema), target, path)
+	case reflect.Slice:
+		elementSchema, _ := listElement(propertySchema)
+		return decodeSlice(value, elementSchema, target, path)
+	}
+
+	return nil
+}
+
+func decodeStruct(value interface{}, fields map[string]interface{}, target reflect.Value, path propertyPath) error {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s is not a struct", path.description())
+	}
+
+	targetType := target.Type()
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, hasTag := field.Tag.Lookup("confidence"); hasTag {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		fieldPath := path.withKey(name)
+		fieldSchema, ok := fields[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not defined in the flag schema", fieldPath.description())
+		}
+		if err := decodeValue(values[name], fieldSchema, target.Field(i), fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeMap(value interface{}, fields map[string]interface{}, target reflect.Value, path propertyPath) error {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s is not a struct", path.description())
+	}
+
+	decoded := reflect.MakeMapWithSize(target.Type(), len(values))
+	for key, fieldValue := range values {
+		fieldPath := path.withKey(key)
+		fieldSchema, ok := fields[key].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not defined in the flag schema", fieldPath.description())
+		}
+		elem := reflect.New(target.Type().Elem()).Elem()
+		if err := decodeValue(fieldValue, fieldSchema, elem, fieldPath); err != nil {
+			return err
+		}
+		decoded.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), elem)
+	}
+	target.Set(decoded)
+
+	return nil
+}
+
+func decodeSlice(value interface{}, elementSchema map[string]interface{}, target reflect.Value, path propertyPath) error {
+	values, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("%s is not a list", path.description())
+	}
+
+	decoded := reflect.MakeSlice(target.Type(), len(values), len(values))
+	for i, element := range values {
+		if err := decodeValue(element, elementSchema, decoded.Index(i), path.withIndex(i)); err != nil {
+			return err
+		}
+	}
+	target.Set(decoded)
+
+	return nil
+}
+
+// targetSchemaKind returns the schema kind a Go type can be decoded from, or reflect.Invalid if there is none.
+func targetSchemaKind(t reflect.Type) reflect.Kind {
+	switch t.Kind() {
+	case reflect.Bool:
+		return reflect.Bool
+	case reflect.String:
+		return reflect.String
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.Int64
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	case reflect.Struct:
+		return reflect.Map
+	case reflect.Slice:
+		return reflect.Slice
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return reflect.Map
+		}
+	}
+
+	return reflect.Invalid
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	}
+
+	return 0, false
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+
+	return 0, false
+}
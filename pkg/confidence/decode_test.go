@@ -0,0 +1,112 @@
+package confidence
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nestedStruct struct {
+	NestedBool *bool `confidence:"nested-boolean-key"`
+}
+
+type structKey struct {
+	Bool     bool         `confidence:"boolean-key"`
+	String   string       `confidence:"string-key"`
+	Double   float64      `confidence:"double-key"`
+	Integer  int          `confidence:"integer-key"`
+	Nullable string       `confidence:"string-key-null-value"`
+	Nested   nestedStruct `confidence:"nested-struct-key"`
+	Ignored  string       `confidence:"-"`
+}
+
+func TestDecodeStruct(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	target := structKey{Nullable: "kept", Ignored: "kept"}
+	detail := client.DecodeFlag(context.Background(), "test-flag.struct-key", &target)
+
+	assert.Equal(t, TargetingMatchReason, detail.Reason)
+	assert.Equal(t, "flags/test-flag/variants/treatment", detail.Variant)
+	assert.Equal(t, false, target.Bool)
+	assert.Equal(t, "treatment-struct", target.String)
+	assert.Equal(t, 123.23, target.Double)
+	assert.Equal(t, 23, target.Integer)
+	assert.Equal(t, "kept", target.Nullable)
+	assert.Equal(t, "kept", target.Ignored)
+	assert.NotNil(t, target.Nested.NestedBool)
+	assert.Equal(t, false, *target.Nested.NestedBool)
+}
+
+func TestDecodeStructTypeMismatchLeavesTargetUntouched(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	target := struct {
+		String string `confidence:"string-key"`
+		Bool   string `confidence:"boolean-key"`
+	}{String: "untouched"}
+	detail := client.DecodeFlag(context.Background(), "test-flag.struct-key", &target)
+
+	assert.Equal(t, ErrorReason, detail.Reason)
+	assert.Equal(t, TypeMismatchCode, detail.ErrorCode)
+	assert.Equal(t, "schema for property struct-key.boolean-key does not match string", detail.ErrorMessage)
+	assert.Equal(t, "untouched", target.String)
+}
+
+func TestDecodeStructFieldMissingFromSchema(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	target := struct {
+		Missing string `confidence:"missing-key"`
+	}{}
+	detail := client.DecodeFlag(context.Background(), "test-flag", &target)
+
+	assert.Equal(t, TypeMismatchCode, detail.ErrorCode)
+	assert.Equal(t, "property missing-key is not defined in the flag schema", detail.ErrorMessage)
+}
+
+func TestDecodeIntoMap(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	var target map[string]interface{}
+	detail := client.DecodeFlag(context.Background(), "test-flag.struct-key", &target)
+
+	assert.Equal(t, TargetingMatchReason, detail.Reason)
+	assert.Equal(t, int64(23), target["integer-key"])
+	assert.Equal(t, "treatment-struct", target["string-key"])
+}
+
+func TestDecodeRequiresPointer(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+
+	detail := client.DecodeFlag(context.Background(), "test-flag", structKey{})
+
+	assert.Equal(t, ErrorReason, detail.Reason)
+	assert.Equal(t, GeneralCode, detail.ErrorCode)
+}
+
+func TestGetTypedValue(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	assert.Equal(t, "treatment", Get(context.Background(), *client, "test-flag.string-key", "default"))
+	assert.Equal(t, int32(40), Get(context.Background(), *client, "test-flag.integer-key", int32(99)))
+	assert.Equal(t, float32(20.203), Get(context.Background(), *client, "test-flag.double-key", float32(99.99)))
+	assert.Equal(t, true, Get(context.Background(), *client, "test-flag.boolean-key-null-value", true))
+}
+
+func TestGetTypedValueMismatch(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	client.PutContext("targeting_key", "user1")
+
+	detail := GetFlag(context.Background(), *client, "test-flag.string-key", 99)
+
+	assert.Equal(t, 99, detail.Value)
+	assert.Equal(t, ErrorReason, detail.Reason)
+	assert.Equal(t, TypeMismatchCode, detail.ErrorCode)
+}
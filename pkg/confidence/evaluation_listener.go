@@ -0,0 +1,179 @@
+package confidence
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Evaluation describes a flag resolved through a Confidence, see EvaluationListener.
+type Evaluation struct {
+	Time         time.Time
+	Flag         string
+	Variant      string
+	Reason       Reason
+	ErrorCode    ErrorCode
+	ErrorMessage string
+	// Context is the evaluation context the flag was resolved with after APIConfig.Redaction, it must not be
+	// modified.
+	Context map[string]interface{}
+	// ContextHash identifies the evaluation context within the process, equal contexts have equal hashes. It is an
+	// HMAC-SHA256 of Context, after redaction, keyed with a random key of the process so that the values of the
+	// context can't be recovered from it. It is empty if the context can't be serialized.
+	ContextHash string
+}
+
+// EvaluationListener is notified after every flag resolved with ResolveFlag or DecodeFlag, including the typed
+// getters. Listeners are called synchronously and must be safe for concurrent use.
+type EvaluationListener interface {
+	OnEvaluation(evaluation Evaluation)
+}
+
+// EvaluationListenerFunc adapts a function to an EvaluationListener.
+type EvaluationListenerFunc func(evaluation Evaluation)
+
+func (f EvaluationListenerFunc) OnEvaluation(evaluation Evaluation) {
+	f(evaluation)
+}
+
+// notifyEvaluation notifies the evaluation listeners of flag resolved with detail.
+func (e Confidence) notifyEvaluation(flag string, detail ResolutionDetail) {
+	if len(e.evaluationListeners) == 0 {
+		return
+	}
+	evaluationContext := e.Config.Redaction.Apply(e.contextMap)
+	evaluation := Evaluation{
+		Time:         time.Now(),
+		Flag:         flag,
+		Variant:      detail.Variant,
+		Reason:       detail.Reason,
+		ErrorCode:    detail.ErrorCode,
+		ErrorMessage: detail.ErrorMessage,
+		Context:      evaluationContext,
+		ContextHash:  contextHash(processHashKey, evaluationContext),
+	}
+	for _, listener := range e.evaluationListeners {
+		listener.OnEvaluation(evaluation)
+	}
+}
+
+// processHashKey keys the context hashes of the evaluations, it is random for every process.
+var processHashKey = newHashKey()
+
+func newHashKey() []byte {
+	key := make([]byte, sha256.Size)
+	// crypto/rand only fails if the system has no source of randomness, there is nothing better to fall back to.
+	_, _ = rand.Read(key)
+	return key
+}
+
+// contextHash returns the hex encoded HMAC-SHA256 of the serialized context keyed with key, or an empty string if the
+// context can't be serialized.
+func contextHash(key []byte, evaluationContext map[string]interface{}) string {
+	// Map keys are serialized in sorted order, which keeps the hash stable.
+	serialized, err := json.Marshal(evaluationContext)
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(serialized)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuditSinkOptions configures an AuditSink.
+type AuditSinkOptions struct {
+	// SampleRate is the share of evaluation contexts whose evaluations are written, from 0 to 1. Sampling is based on
+	// the context hash, so the evaluations of a context are either all written or all skipped. Zero writes every
+	// evaluation.
+	SampleRate float64
+	// Redaction selects the context attributes scrubbed from the written context, in addition to the ones scrubbed by
+	// the APIConfig of the Confidence.
+	Redaction Redaction
+	// OmitContext only writes the context hash.
+	OmitContext bool
+	// HashKey keys the HMAC-SHA256 context hashes written, so that the hashes of different processes can be compared.
+	// It must be kept secret. A random key of the process is used if it is empty, as for Evaluation.ContextHash.
+	HashKey []byte
+}
+
+// AuditSink is an EvaluationListener writing every evaluation as a line of JSON, for audits of which variant each
+// context received:
+//
+//	{"time":"...","flag":"my-flag.enabled","variant":"flags/my-flag/variants/on","reason":"TARGETING_MATCH",
+//	 "context_hash":"...","context":{"targeting_key":"user1"}}
+//
+// Errors writing to the underlying writer are ignored.
+type AuditSink struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	options AuditSinkOptions
+}
+
+// auditRecord is the JSON line written by AuditSink.
+type auditRecord struct {
+	Time         string                 `json:"time"`
+	Flag         string                 `json:"flag"`
+	Variant      string                 `json:"variant,omitempty"`
+	Reason       Reason                 `json:"reason"`
+	ErrorCode    ErrorCode              `json:"error_code,omitempty"`
+	ErrorMessage string                 `json:"error_message,omitempty"`
+	ContextHash  string                 `json:"context_hash"`
+	Context      map[string]interface{} `json:"context,omitempty"`
+}
+
+// NewAuditSink returns an AuditSink writing to writer.
+func NewAuditSink(writer io.Writer, options AuditSinkOptions) *AuditSink {
+	return &AuditSink{writer: writer, options: options}
+}
+
+func (s *AuditSink) OnEvaluation(evaluation Evaluation) {
+	// The hash is computed from the context as written, so that redacted values don't affect it.
+	evaluationContext := s.options.Redaction.Apply(evaluation.Context)
+	key := s.options.HashKey
+	if len(key) == 0 {
+		key = processHashKey
+	}
+	hash := contextHash(key, evaluationContext)
+	if !s.sampled(hash) {
+		return
+	}
+	record := auditRecord{
+		Time:         evaluation.Time.UTC().Format(time.RFC3339Nano),
+		Flag:         evaluation.Flag,
+		Variant:      evaluation.Variant,
+		Reason:       evaluation.Reason,
+		ErrorCode:    evaluation.ErrorCode,
+		ErrorMessage: evaluation.ErrorMessage,
+		ContextHash:  hash,
+	}
+	if !s.options.OmitContext {
+		record.Context = evaluationContext
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.writer.Write(append(line, '\n'))
+}
+
+// sampled reports whether the evaluations of the context with contextHash are written.
+func (s *AuditSink) sampled(contextHash string) bool {
+	if s.options.SampleRate <= 0 || s.options.SampleRate >= 1 {
+		return true
+	}
+	sum, err := hex.DecodeString(contextHash)
+	if err != nil || len(sum) < 8 {
+		return false
+	}
+	return float64(binary.BigEndian.Uint64(sum))/math.MaxUint64 < s.options.SampleRate
+}
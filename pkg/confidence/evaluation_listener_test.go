@@ -0,0 +1,148 @@
+package confidence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluationListenersAreNotified(t *testing.T) {
+	var evaluations []Evaluation
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).
+		AddEvaluationListener(EvaluationListenerFunc(func(evaluation Evaluation) {
+			evaluations = append(evaluations, evaluation)
+		})).Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+	confidence.GetStringFlag(context.Background(), "test-flag.boolean-key", "default")
+
+	assert.Len(t, evaluations, 2)
+	assert.Equal(t, "test-flag.boolean-key", evaluations[0].Flag)
+	assert.Equal(t, "flags/test-flag/variants/treatment", evaluations[0].Variant)
+	assert.Equal(t, TargetingMatchReason, evaluations[0].Reason)
+	assert.Equal(t, map[string]interface{}{"targeting_key": "user1"}, evaluations[0].Context)
+	assert.Equal(t, contextHash(processHashKey, map[string]interface{}{"targeting_key": "user1"}),
+		evaluations[0].ContextHash)
+	assert.WithinDuration(t, time.Now(), evaluations[0].Time, time.Minute)
+	assert.Equal(t, TypeMismatchCode, evaluations[1].ErrorCode)
+	assert.Equal(t, evaluations[0].ContextHash, evaluations[1].ContextHash)
+}
+
+func TestDecodedFlagsNotifyEvaluationListeners(t *testing.T) {
+	var evaluations []Evaluation
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedError: errors.New("unavailable"), TestingT: t}).
+		AddEvaluationListener(EvaluationListenerFunc(func(evaluation Evaluation) {
+			evaluations = append(evaluations, evaluation)
+		})).Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	Get(context.Background(), confidence, "test-flag.string-key", "default")
+
+	assert.Len(t, evaluations, 1)
+	assert.Equal(t, GeneralCode, evaluations[0].ErrorCode)
+}
+
+func TestAuditSinkWritesJsonLines(t *testing.T) {
+	var out bytes.Buffer
+	sink := NewAuditSink(&out, AuditSinkOptions{Redaction: Redaction{DenyKeys: []string{"email"}}})
+	evaluationContext := map[string]interface{}{
+		"targeting_key": "user1",
+		"email":         "user1@example.com",
+		"user":          map[string]interface{}{"email": "user1@example.com", "country": "SE"},
+	}
+
+	sink.OnEvaluation(Evaluation{
+		Time:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Flag:        "test-flag.boolean-key",
+		Variant:     "flags/test-flag/variants/treatment",
+		Reason:      TargetingMatchReason,
+		Context:     evaluationContext,
+		ContextHash: contextHash(processHashKey, evaluationContext),
+	})
+	sink.OnEvaluation(Evaluation{Flag: "other-flag", Reason: ErrorReason, ErrorCode: FlagNotFoundCode})
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "2024-01-02T03:04:05Z", record["time"])
+	assert.Equal(t, "flags/test-flag/variants/treatment", record["variant"])
+	assert.Equal(t, "TARGETING_MATCH", record["reason"])
+	assert.Equal(t, contextHash(processHashKey, map[string]interface{}{
+		"targeting_key": "user1",
+		"email":         "[REDACTED]",
+		"user":          map[string]interface{}{"email": "[REDACTED]", "country": "SE"},
+	}), record["context_hash"])
+	assert.Equal(t, map[string]interface{}{
+		"targeting_key": "user1",
+		"email":         "[REDACTED]",
+		"user":          map[string]interface{}{"email": "[REDACTED]", "country": "SE"},
+	}, record["context"])
+	assert.Equal(t, "user1@example.com", evaluationContext["email"])
+	assert.Contains(t, lines[1], `"error_code":"FLAG_NOT_FOUND"`)
+}
+
+func TestAuditSinkCanOmitTheContext(t *testing.T) {
+	var out bytes.Buffer
+	sink := NewAuditSink(&out, AuditSinkOptions{OmitContext: true})
+	evaluationContext := map[string]interface{}{"targeting_key": "user1"}
+
+	sink.OnEvaluation(Evaluation{Flag: "test-flag", Context: evaluationContext,
+		ContextHash: contextHash(processHashKey, evaluationContext)})
+
+	assert.NotContains(t, out.String(), "user1")
+	assert.Contains(t, out.String(), contextHash(processHashKey, evaluationContext))
+}
+
+func TestAuditSinkSamplesByContext(t *testing.T) {
+	var out bytes.Buffer
+	sink := NewAuditSink(&out, AuditSinkOptions{SampleRate: 0.5})
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		evaluationContext := map[string]interface{}{"targeting_key": fmt.Sprintf("user%d", i)}
+		evaluation := Evaluation{Flag: "test-flag", Context: evaluationContext}
+		before := out.Len()
+		sink.OnEvaluation(evaluation)
+		written := out.Len() > before
+		if written {
+			sampled++
+		}
+		before = out.Len()
+		sink.OnEvaluation(evaluation)
+		assert.Equal(t, written, out.Len() > before)
+	}
+
+	assert.InDelta(t, 500, sampled, 100)
+}
+
+func TestAuditSinkHashesWithTheHashKey(t *testing.T) {
+	evaluation := Evaluation{Flag: "test-flag", Context: map[string]interface{}{"targeting_key": "user1"}}
+	hashes := map[string]bool{}
+	for _, key := range [][]byte{[]byte("key1"), []byte("key1"), []byte("key2"), nil} {
+		var out bytes.Buffer
+		NewAuditSink(&out, AuditSinkOptions{HashKey: key}).OnEvaluation(evaluation)
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+		hashes[record["context_hash"].(string)] = true
+	}
+
+	assert.Len(t, hashes, 3)
+	assert.False(t, hashes[hex.EncodeToString(sha256Sum(`{"targeting_key":"user1"}`))])
+}
+
+func sha256Sum(value string) []byte {
+	sum := sha256.Sum256([]byte(value))
+	return sum[:]
+}
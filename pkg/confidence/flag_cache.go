@@ -0,0 +1,115 @@
+package confidence
+
+import (
+	"container/list"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// defaultFlagCacheSize bounds the number of cached flag resolves, the least recently used ones are evicted first.
+const defaultFlagCacheSize = 10000
+
+// flagCache holds resolved flags per flag name and evaluation context.
+type flagCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+type flagCacheEntry struct {
+	key      string
+	response ResolveResponse
+	storedAt time.Time
+	// unapplied is set for the flags stored by WarmUp, they are only served stale since serving them fresh would skip
+	// applying them.
+	unapplied bool
+}
+
+func newFlagCache(ttl time.Duration, size int) *flagCache {
+	return &flagCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// flagCacheKey returns the cache key for flag resolved with evaluationContext, or false if the context can't be
+// serialized.
+func flagCacheKey(flag string, evaluationContext map[string]interface{}) (string, bool) {
+	// Map keys are serialized in sorted order, which keeps the key stable.
+	serialized, err := json.Marshal(evaluationContext)
+	if err != nil {
+		return "", false
+	}
+	return flag + "\x00" + string(serialized), true
+}
+
+// get returns the cached response for key, and whether it is still within the TTL. Unapplied responses are never
+// fresh.
+func (c *flagCache) get(key string) (ResolveResponse, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, found := c.entries[key]
+	if !found {
+		return ResolveResponse{}, false, false
+	}
+	c.order.MoveToFront(element)
+	entry := element.Value.(*flagCacheEntry)
+	return entry.response, !entry.unapplied && c.now().Sub(entry.storedAt) < c.ttl, true
+}
+
+func (c *flagCache) put(key string, response ResolveResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, found := c.entries[key]; found {
+		entry := element.Value.(*flagCacheEntry)
+		entry.response = response
+		entry.storedAt = c.now()
+		entry.unapplied = false
+		c.order.MoveToFront(element)
+		return
+	}
+	c.push(&flagCacheEntry{key: key, response: response, storedAt: c.now()})
+}
+
+// putUnapplied stores a response resolved without applying its flags, unless key is cached already.
+func (c *flagCache) putUnapplied(key string, response ResolveResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.entries[key]; found {
+		return
+	}
+	c.push(&flagCacheEntry{key: key, response: response, storedAt: c.now(), unapplied: true})
+}
+
+// push adds entry as the most recently used one and evicts the least recently used entry if the cache is full. The
+// caller must hold mu.
+func (c *flagCache) push(entry *flagCacheEntry) {
+	c.entries[entry.key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*flagCacheEntry).key)
+	}
+}
+
+// flagsChanged reports whether refreshed resolves a flag to a different variant or value than cached.
+func flagsChanged(cached ResolveResponse, refreshed ResolveResponse) bool {
+	if len(cached.ResolvedFlags) != len(refreshed.ResolvedFlags) {
+		return true
+	}
+	for i, flag := range cached.ResolvedFlags {
+		other := refreshed.ResolvedFlags[i]
+		if flag.Variant != other.Variant || flag.Reason != other.Reason || !reflect.DeepEqual(flag.Value, other.Value) {
+			return true
+		}
+	}
+	return false
+}
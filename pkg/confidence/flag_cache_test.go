@@ -0,0 +1,218 @@
+package confidence
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sequenceResolveClient struct {
+	responses []ResolveResponse
+	errors    []error
+	calls     int
+}
+
+func (r *sequenceResolveClient) SendResolveRequest(_ context.Context, _ ResolveRequest) (ResolveResponse, error) {
+	i := r.calls
+	if i >= len(r.responses) {
+		i = len(r.responses) - 1
+	}
+	r.calls++
+	return r.responses[i], r.errors[i]
+}
+
+type fakeClock struct {
+	current time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.current
+}
+
+func newCachingConfidence(client ResolveClient, config APIConfig, clock *fakeClock) (*Confidence, *[]StatusEvent) {
+	config.APIKey = "apiKey"
+	confidence := NewConfidenceBuilder().
+		SetAPIConfig(config).
+		SetResolveClient(client).
+		SetLogger(slog.Default()).
+		Build()
+	if confidence.cache != nil {
+		confidence.cache.now = clock.now
+	}
+	if confidence.breaker != nil {
+		confidence.breaker.now = clock.now
+	}
+	var events []StatusEvent
+	confidence.OnStatusEvent(func(event StatusEvent) {
+		events = append(events, event)
+	})
+	confidence.PutContext("targeting_key", "user1")
+	return &confidence, &events
+}
+
+func TestFreshFlagsAreServedFromCache(t *testing.T) {
+	client := &sequenceResolveClient{responses: []ResolveResponse{templateResponse()}, errors: []error{nil}}
+	clock := &fakeClock{current: time.Now()}
+	confidence, _ := newCachingConfidence(client, APIConfig{CacheTTL: time.Minute}, clock)
+
+	first := confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	second := confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, 1, client.calls)
+	assert.Equal(t, "treatment", second.Value)
+	assert.Equal(t, TargetingMatchReason, first.Reason)
+	assert.Equal(t, CachedReason, second.Reason)
+	assert.Equal(t, "network", first.FlagMetadata[FlagMetadataSource])
+	assert.Equal(t, "cache", second.FlagMetadata[FlagMetadataSource])
+}
+
+func TestCacheIsKeyedByContext(t *testing.T) {
+	client := &sequenceResolveClient{responses: []ResolveResponse{templateResponse()}, errors: []error{nil}}
+	clock := &fakeClock{current: time.Now()}
+	confidence, _ := newCachingConfidence(client, APIConfig{CacheTTL: time.Minute}, clock)
+
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	confidence.WithContext(map[string]interface{}{"country": "SE"}).
+		GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, 2, client.calls)
+}
+
+func TestStaleFlagsAreServedWhenResolverFails(t *testing.T) {
+	client := &sequenceResolveClient{
+		responses: []ResolveResponse{templateResponse(), {}, templateResponse()},
+		errors:    []error{nil, errors.New("unavailable"), nil},
+	}
+	clock := &fakeClock{current: time.Now()}
+	confidence, events := newCachingConfidence(client, APIConfig{CacheTTL: time.Minute}, clock)
+
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	clock.current = clock.current.Add(2 * time.Minute)
+	stale := confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	clock.current = clock.current.Add(2 * time.Minute)
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, "treatment", stale.Value)
+	assert.Equal(t, "cache", stale.FlagMetadata[FlagMetadataSource])
+	assert.Equal(t, []StatusEventType{StatusReady, StatusStale, StatusReady}, eventTypes(*events))
+}
+
+func TestRefreshedFlagChangesAreReported(t *testing.T) {
+	changed := templateResponse()
+	changed.ResolvedFlags[0].Variant = "flags/test-flag/variants/control"
+	client := &sequenceResolveClient{
+		responses: []ResolveResponse{templateResponse(), templateResponse(), changed},
+		errors:    []error{nil, nil, nil},
+	}
+	clock := &fakeClock{current: time.Now()}
+	confidence, events := newCachingConfidence(client, APIConfig{CacheTTL: time.Minute}, clock)
+
+	for i := 0; i < 3; i++ {
+		confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+		clock.current = clock.current.Add(2 * time.Minute)
+	}
+
+	assert.Equal(t, 3, client.calls)
+	assert.Equal(t, []StatusEvent{{
+		Type:    StatusReady,
+		Message: "flags resolved",
+	}, {
+		Type:         StatusConfigurationChanged,
+		Message:      "flag values changed",
+		ChangedFlags: []string{"test-flag"},
+	}}, *events)
+}
+
+func TestFirstResolveReportsReady(t *testing.T) {
+	client := &sequenceResolveClient{responses: []ResolveResponse{templateResponse()}, errors: []error{nil}}
+	confidence, events := newCachingConfidence(client, APIConfig{}, &fakeClock{current: time.Now()})
+
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, []StatusEventType{StatusReady}, eventTypes(*events))
+}
+
+func TestCircuitOpensAfterConsecutiveFailures(t *testing.T) {
+	client := &sequenceResolveClient{
+		responses: []ResolveResponse{{}, {}, templateResponse()},
+		errors:    []error{errors.New("unavailable"), errors.New("unavailable"), nil},
+	}
+	clock := &fakeClock{current: time.Now()}
+	config := APIConfig{CircuitBreakerThreshold: 2, CircuitBreakerCooldown: time.Minute}
+	confidence, events := newCachingConfidence(client, config, clock)
+
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	rejected := confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, 2, client.calls)
+	assert.Equal(t, "default", rejected.Value)
+	assert.Equal(t, ProviderNotReadyCode, rejected.ErrorCode)
+	assert.Equal(t, []StatusEventType{StatusError}, eventTypes(*events))
+
+	clock.current = clock.current.Add(2 * time.Minute)
+	recovered := confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, "treatment", recovered.Value)
+	assert.Equal(t, []StatusEventType{StatusError, StatusReady}, eventTypes(*events))
+}
+
+func TestCacheEvictsLeastRecentlyUsedFlags(t *testing.T) {
+	cache := newFlagCache(time.Minute, 2)
+	cache.put("a", ResolveResponse{ResolveToken: "a"})
+	cache.put("b", ResolveResponse{ResolveToken: "b"})
+	cache.get("a")
+	cache.put("c", ResolveResponse{ResolveToken: "c"})
+
+	_, _, foundA := cache.get("a")
+	_, _, foundB := cache.get("b")
+	assert.True(t, foundA)
+	assert.False(t, foundB)
+}
+
+func eventTypes(events []StatusEvent) []StatusEventType {
+	var types []StatusEventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestWarmUpCachesFlagsUntilApplied(t *testing.T) {
+	client := &sequenceResolveClient{
+		responses: []ResolveResponse{templateResponse(), templateResponse()},
+		errors:    []error{nil, nil},
+	}
+	confidence, _ := newCachingConfidence(client, APIConfig{CacheTTL: time.Minute}, &fakeClock{current: time.Now()})
+
+	assert.NoError(t, confidence.WarmUp(context.Background()))
+	cacheKey, _ := flagCacheKey("flags/test-flag", confidence.contextMap)
+	_, fresh, found := confidence.cache.get(cacheKey)
+	assert.True(t, found)
+	assert.False(t, fresh)
+
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	cached := confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, 2, client.calls)
+	assert.Equal(t, CachedReason, cached.Reason)
+}
+
+func TestWarmedUpFlagsAreServedStale(t *testing.T) {
+	client := &sequenceResolveClient{
+		responses: []ResolveResponse{templateResponse(), {}},
+		errors:    []error{nil, errors.New("unavailable")},
+	}
+	confidence, _ := newCachingConfidence(client, APIConfig{CacheTTL: time.Minute}, &fakeClock{current: time.Now()})
+
+	assert.NoError(t, confidence.WarmUp(context.Background()))
+	stale := confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	assert.Equal(t, "treatment", stale.Value)
+	assert.Equal(t, string(ResolveSourceCache), stale.FlagMetadata[FlagMetadataSource])
+}
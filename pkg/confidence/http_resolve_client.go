@@ -3,20 +3,22 @@ package confidence
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
-
-	"google.golang.org/protobuf/proto"
 )
 
 type HttpResolveClient struct {
 	Client *http.Client
 	Config APIConfig
-	traces chan *ProtoLibraryTraces_ProtoTrace
+	// Logger is set to the logger of the Confidence the client is built into if nil, it falls back to the default
+	// logger otherwise.
+	Logger    *slog.Logger
+	telemetry *telemetryCollector
 }
 
 func NewHttpResolveClient(config APIConfig) *HttpResolveClient {
@@ -24,21 +26,17 @@ func NewHttpResolveClient(config APIConfig) *HttpResolveClient {
 		Client: &http.Client{
 			Timeout: config.ResolveTimeout,
 		},
-		Config: config,
-		traces: make(chan *ProtoLibraryTraces_ProtoTrace, 1000), // Buffer size of 1000 should be sufficient
+		Config:    config,
+		telemetry: newTelemetryCollector(config),
 	}
 }
 
 func (client *HttpResolveClient) PullTraces() []*ProtoLibraryTraces_ProtoTrace {
 	traces := make([]*ProtoLibraryTraces_ProtoTrace, 0)
-	for {
-		select {
-		case trace := <-client.traces:
-			traces = append(traces, trace)
-		default:
-			return traces
-		}
+	for _, trace := range client.telemetry.pull() {
+		traces = append(traces, trace.trace)
 	}
+	return traces
 }
 
 func parseErrorMessage(body io.ReadCloser) string {
@@ -52,48 +50,19 @@ func parseErrorMessage(body io.ReadCloser) string {
 	return resolveError.Message
 }
 
-func (client *HttpResolveClient) appendTrace(startTime time.Time, status ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_ProtoStatus) {
-	if client.Config.DisableTelemetry {
-		return
-	}
-	select {
-	case client.traces <- &ProtoLibraryTraces_ProtoTrace{
-		Id: ProtoLibraryTraces_PROTO_TRACE_ID_RESOLVE_LATENCY,
-		Trace: &ProtoLibraryTraces_ProtoTrace_RequestTrace{
-			RequestTrace: &ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace{
-				MillisecondDuration: uint64(time.Since(startTime).Milliseconds()),
-				Status:              status,
-			},
-		},
-	}:
-	default:
-		// Channel is full, drop the trace
-	}
+// appendTrace records the outcome of request in the telemetry and logs it at debug level.
+func (client *HttpResolveClient) appendTrace(request ResolveRequest, startTime time.Time, status ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_ProtoStatus) {
+	latency := time.Since(startTime)
+	client.telemetry.recordRequest(request.sdkInfo(), latency, status)
+	loggerOrDefault(client.Logger).Debug("Resolve request completed",
+		LogKeyFlag, strings.Join(request.Flags, ","),
+		LogKeyStatus, strings.ToLower(strings.TrimPrefix(status.String(), "PROTO_STATUS_")),
+		LogKeyLatencyMs, latency.Milliseconds())
 }
 
-func (client *HttpResolveClient) addTelemetryHeader(req *http.Request) {
-	if client.Config.DisableTelemetry {
-		// Clear any existing traces when telemetry is disabled
-		client.PullTraces()
-		return
-	}
-
-	traces := client.PullTraces()
-	monitoring := &ProtoMonitoring{
-		Platform: ProtoPlatform_PROTO_PLATFORM_GO,
-		LibraryTraces: []*ProtoLibraryTraces{
-			{
-				Library:        ProtoLibraryTraces_PROTO_LIBRARY_CONFIDENCE,
-				LibraryVersion: SDK_VERSION,
-				Traces:         traces,
-			},
-		},
-	}
-
-	monitoringBytes, err := proto.Marshal(monitoring)
-	if err == nil {
-		monitoringBase64 := base64.StdEncoding.EncodeToString(monitoringBytes)
-		req.Header.Set("X-CONFIDENCE-TELEMETRY", monitoringBase64)
+func (client *HttpResolveClient) addTelemetryHeader(req *http.Request, request ResolveRequest) {
+	if header, ok := client.telemetry.header(request.sdkInfo()); ok {
+		req.Header.Set("X-CONFIDENCE-TELEMETRY", header)
 	}
 }
 
@@ -111,7 +80,7 @@ func (client *HttpResolveClient) SendResolveRequest(ctx context.Context,
 		return ResolveResponse{}, err
 	}
 
-	client.addTelemetryHeader(req)
+	client.addTelemetryHeader(req, request)
 
 	startTime := time.Now()
 	resp, err := client.Client.Do(req)
@@ -120,13 +89,13 @@ func (client *HttpResolveClient) SendResolveRequest(ctx context.Context,
 		if err, ok := err.(interface{ Timeout() bool }); ok && err.Timeout() {
 			status = ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_TIMEOUT
 		}
-		client.appendTrace(startTime, status)
+		client.appendTrace(request, startTime, status)
 		return ResolveResponse{}, fmt.Errorf("error when calling the resolver service: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		client.appendTrace(startTime, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_ERROR)
+		client.appendTrace(request, startTime, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_ERROR)
 		return ResolveResponse{},
 			fmt.Errorf("got '%s' error from the resolver service: %s", resp.Status, parseErrorMessage(resp.Body))
 	}
@@ -136,10 +105,10 @@ func (client *HttpResolveClient) SendResolveRequest(ctx context.Context,
 	decoder.UseNumber()
 	err = decoder.Decode(&result)
 	if err != nil {
-		client.appendTrace(startTime, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_ERROR)
+		client.appendTrace(request, startTime, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_ERROR)
 		return ResolveResponse{}, fmt.Errorf("error when deserializing response from the resolver service: %w", err)
 	}
 
-	client.appendTrace(startTime, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_SUCCESS)
+	client.appendTrace(request, startTime, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_SUCCESS)
 	return result, nil
 }
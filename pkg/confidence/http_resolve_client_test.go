@@ -369,3 +369,46 @@ func TestHttpResolveClient_TelemetryHeader_Disabled(t *testing.T) {
 	traces := client.PullTraces()
 	assert.Equal(t, 0, len(traces))
 }
+
+func TestHttpResolveClient_TelemetryHeader_GroupsTracesByLibrary(t *testing.T) {
+	var receivedHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = append(receivedHeaders, r.Header.Get("X-CONFIDENCE-TELEMETRY"))
+		json.NewEncoder(w).Encode(ResolveResponse{})
+	}))
+	defer server.Close()
+
+	client := NewHttpResolveClient(APIConfig{APIKey: "test-key", APIResolveBaseUrl: server.URL})
+	openFeatureRequest := ResolveRequest{
+		ClientSecret: "test-secret",
+		Flags:        []string{"test-flag"},
+		Sdk:          sdk{"SDK_ID_GO_PROVIDER", "1.2.3"},
+		library:      ProtoLibraryTraces_PROTO_LIBRARY_OPEN_FEATURE,
+	}
+	confidenceRequest := ResolveRequest{
+		ClientSecret: "test-secret",
+		Flags:        []string{"test-flag"},
+		Sdk:          sdk{SDK_ID, SDK_VERSION},
+	}
+
+	_, err := client.SendResolveRequest(context.Background(), openFeatureRequest)
+	assert.NoError(t, err)
+	client.telemetry.recordCount(SdkInfo{}, ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH)
+	_, err = client.SendResolveRequest(context.Background(), confidenceRequest)
+	assert.NoError(t, err)
+
+	monitoringBytes, err := base64.StdEncoding.DecodeString(receivedHeaders[1])
+	assert.NoError(t, err)
+	var monitoring ProtoMonitoring
+	assert.NoError(t, proto.Unmarshal(monitoringBytes, &monitoring))
+
+	assert.Equal(t, 2, len(monitoring.LibraryTraces))
+	assert.Equal(t, ProtoLibraryTraces_PROTO_LIBRARY_CONFIDENCE, monitoring.LibraryTraces[0].Library)
+	assert.Equal(t, SDK_VERSION, monitoring.LibraryTraces[0].LibraryVersion)
+	assert.Equal(t, 1, len(monitoring.LibraryTraces[0].Traces))
+	assert.Equal(t, ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH, monitoring.LibraryTraces[0].Traces[0].Id)
+	assert.Equal(t, ProtoLibraryTraces_PROTO_LIBRARY_OPEN_FEATURE, monitoring.LibraryTraces[1].Library)
+	assert.Equal(t, "1.2.3", monitoring.LibraryTraces[1].LibraryVersion)
+	assert.Equal(t, 1, len(monitoring.LibraryTraces[1].Traces))
+	assert.Equal(t, ProtoLibraryTraces_PROTO_TRACE_ID_RESOLVE_LATENCY, monitoring.LibraryTraces[1].Traces[0].Id)
+}
@@ -0,0 +1,96 @@
+package confidence
+
+import (
+	"context"
+	"errors"
+)
+
+// Instrumentation is notified of the flag evaluations, resolve requests and event uploads of a Confidence. It is
+// meant for integrations with observability libraries, see the otel package.
+//
+// Each Start method is called before the operation with the context of the caller. It returns the context to run the
+// operation with, e.g. holding a span, and a function that is called once the operation is done. Implementations
+// must be safe for concurrent use.
+type Instrumentation interface {
+	// StartEvaluation is called for every flag resolved with ResolveFlag or DecodeFlag, including the typed getters.
+	StartEvaluation(ctx context.Context, flag string) (context.Context, func(ResolutionDetail))
+	// StartResolveRequest is called for every request sent to the resolve client, flags served from the cache are
+	// not requested. The request of WarmUp has no flags, it resolves all of them.
+	StartResolveRequest(ctx context.Context, request ResolveRequest) (context.Context, func(ResolveResponse, error))
+	// StartEventUpload is called for every batch of tracked events uploaded.
+	StartEventUpload(ctx context.Context, request EventBatchRequest) (context.Context, func(error))
+}
+
+// TelemetryInstrumentation is an Instrumentation that is also notified of the telemetry traces dropped because too
+// many were recorded between two resolve requests, see APIConfig.DisableTelemetry.
+type TelemetryInstrumentation interface {
+	Instrumentation
+	TelemetryTraceDropped()
+}
+
+// Statuses of resolve requests, they match the statuses of the Confidence telemetry.
+const (
+	ResolveStatusSuccess = "success"
+	ResolveStatusError   = "error"
+	ResolveStatusTimeout = "timeout"
+)
+
+// ResolveStatus returns the status of a resolve request that failed with err, or ResolveStatusSuccess if err is nil.
+// It is meant for Instrumentation implementations reporting the outcome of resolve requests.
+func ResolveStatus(err error) string {
+	if err == nil {
+		return ResolveStatusSuccess
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &timeout) && timeout.Timeout()) {
+		return ResolveStatusTimeout
+	}
+	return ResolveStatusError
+}
+
+// instrumentations notifies several Instrumentation in order, the zero value notifies none.
+type instrumentations []Instrumentation
+
+func (is instrumentations) StartEvaluation(ctx context.Context, flag string) (context.Context, func(ResolutionDetail)) {
+	dones := make([]func(ResolutionDetail), 0, len(is))
+	for _, instrumentation := range is {
+		var done func(ResolutionDetail)
+		ctx, done = instrumentation.StartEvaluation(ctx, flag)
+		dones = append(dones, done)
+	}
+	return ctx, func(detail ResolutionDetail) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](detail)
+		}
+	}
+}
+
+func (is instrumentations) StartResolveRequest(ctx context.Context,
+	request ResolveRequest) (context.Context, func(ResolveResponse, error)) {
+	dones := make([]func(ResolveResponse, error), 0, len(is))
+	for _, instrumentation := range is {
+		var done func(ResolveResponse, error)
+		ctx, done = instrumentation.StartResolveRequest(ctx, request)
+		dones = append(dones, done)
+	}
+	return ctx, func(response ResolveResponse, err error) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](response, err)
+		}
+	}
+}
+
+func (is instrumentations) StartEventUpload(ctx context.Context,
+	request EventBatchRequest) (context.Context, func(error)) {
+	dones := make([]func(error), 0, len(is))
+	for _, instrumentation := range is {
+		var done func(error)
+		ctx, done = instrumentation.StartEventUpload(ctx, request)
+		dones = append(dones, done)
+	}
+	return ctx, func(err error) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](err)
+		}
+	}
+}
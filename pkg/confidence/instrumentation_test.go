@@ -0,0 +1,71 @@
+package confidence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingInstrumentation struct {
+	name  string
+	calls *[]string
+}
+
+func (r recordingInstrumentation) StartEvaluation(ctx context.Context, flag string) (context.Context, func(ResolutionDetail)) {
+	*r.calls = append(*r.calls, r.name+" start evaluation "+flag)
+	return ctx, func(detail ResolutionDetail) {
+		*r.calls = append(*r.calls, r.name+" end evaluation "+string(detail.Reason))
+	}
+}
+
+func (r recordingInstrumentation) StartResolveRequest(ctx context.Context,
+	request ResolveRequest) (context.Context, func(ResolveResponse, error)) {
+	*r.calls = append(*r.calls, r.name+" start request "+request.Flags[0])
+	return ctx, func(ResolveResponse, error) {
+		*r.calls = append(*r.calls, r.name+" end request")
+	}
+}
+
+func (r recordingInstrumentation) StartEventUpload(ctx context.Context,
+	_ EventBatchRequest) (context.Context, func(error)) {
+	return ctx, func(error) {}
+}
+
+func TestInstrumentationsAreNotifiedInOrder(t *testing.T) {
+	var calls []string
+	builder := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).
+		AddInstrumentation(recordingInstrumentation{name: "first", calls: &calls})
+	confidence := builder.AddInstrumentation(recordingInstrumentation{name: "second", calls: &calls}).Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+
+	assert.Equal(t, []string{
+		"first start evaluation test-flag.boolean-key",
+		"second start evaluation test-flag.boolean-key",
+		"first start request flags/test-flag",
+		"second start request flags/test-flag",
+		"second end request",
+		"first end request",
+		"second end evaluation TARGETING_MATCH",
+		"first end evaluation TARGETING_MATCH",
+	}, calls)
+	assert.Len(t, builder.instrumentation, 1)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "i/o timeout" }
+
+func (timeoutError) Timeout() bool { return true }
+
+func TestResolveStatus(t *testing.T) {
+	assert.Equal(t, ResolveStatusSuccess, ResolveStatus(nil))
+	assert.Equal(t, ResolveStatusTimeout, ResolveStatus(fmt.Errorf("resolving: %w", context.DeadlineExceeded)))
+	assert.Equal(t, ResolveStatusTimeout, ResolveStatus(fmt.Errorf("resolving: %w", timeoutError{})))
+	assert.Equal(t, ResolveStatusError, ResolveStatus(errors.New("unavailable")))
+}
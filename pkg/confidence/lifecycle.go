@@ -0,0 +1,65 @@
+package confidence
+
+import (
+	"context"
+	"sync"
+)
+
+// lifecycle tracks the background work started by a Confidence, such as event uploads, so that it can be flushed
+// before the Confidence is closed. The zero value is ready to use.
+type lifecycle struct {
+	mu      sync.Mutex
+	closed  bool
+	pending int
+	// idle is closed once pending drops back to zero, it is nil while no work is pending.
+	idle chan struct{}
+}
+
+// begin registers a unit of background work, it returns false if the lifecycle has been closed.
+func (l *lifecycle) begin() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return false
+	}
+	if l.pending == 0 {
+		l.idle = make(chan struct{})
+	}
+	l.pending++
+	return true
+}
+
+// end marks a unit of background work registered with begin as done.
+func (l *lifecycle) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending--
+	if l.pending == 0 {
+		close(l.idle)
+		l.idle = nil
+	}
+}
+
+// wait blocks until all background work registered so far is done or ctx is done.
+func (l *lifecycle) wait(ctx context.Context) error {
+	l.mu.Lock()
+	idle := l.idle
+	l.mu.Unlock()
+	if idle == nil {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops new background work from being registered.
+func (l *lifecycle) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+}
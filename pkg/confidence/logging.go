@@ -0,0 +1,63 @@
+package confidence
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Attribute keys of the log records written by the SDK. Every component logs through the logger set with
+// ConfidenceBuilder.SetLogger and uses these keys, see the Logging section of the README for the records written.
+const (
+	// LogKeyFlag is the evaluated flag, as passed by the caller, or the name of a resolved flag.
+	LogKeyFlag = "flag"
+	// LogKeyVariant is the variant a flag resolved to.
+	LogKeyVariant = "variant"
+	// LogKeyReason is the reason of an evaluation, see Reason.
+	LogKeyReason = "reason"
+	// LogKeyErrorCode is the error code of a failed evaluation, see ErrorCode.
+	LogKeyErrorCode = "error_code"
+	// LogKeyLatencyMs is the duration of a resolve or an upload, in milliseconds.
+	LogKeyLatencyMs = "latency_ms"
+	// LogKeyError is the error an operation failed with.
+	LogKeyError = "error"
+	// LogKeyEvent is the name of a tracked event, or the type of an OpenFeature provider event.
+	LogKeyEvent = "event"
+	// LogKeyStatus is the outcome of a resolve request: success, error or timeout.
+	LogKeyStatus = "status"
+	// LogKeyHTTPStatus is the HTTP status of a failed response from the Confidence services.
+	LogKeyHTTPStatus = "http_status"
+	// LogKeyConfig is the APIConfig of a Confidence, with the client secret masked.
+	LogKeyConfig = "config"
+	// LogKeyPayload is the payload to paste in the Resolve tester, see APIConfig.EnableResolveTesterHint.
+	LogKeyPayload = "payload"
+	// LogKeyDomain is the OpenFeature domain of the provider a record was written for, see SdkInfo.
+	LogKeyDomain = "domain"
+	// LogKeyInstance numbers the Confidence instances built without a logger, in the order they were built.
+	LogKeyInstance = "confidence_instance"
+)
+
+// instanceIds numbers the instances logging to the default logger.
+var instanceIds atomic.Int64
+
+// logEvaluation logs the evaluation of flag at debug level.
+func (e Confidence) logEvaluation(flag string, detail ResolutionDetail, latency time.Duration) {
+	attributes := []any{
+		LogKeyFlag, flag,
+		LogKeyVariant, detail.Variant,
+		LogKeyReason, detail.Reason,
+		LogKeyLatencyMs, latency.Milliseconds(),
+	}
+	if detail.ErrorCode != "" {
+		attributes = append(attributes, LogKeyErrorCode, detail.ErrorCode)
+	}
+	e.log().Debug("Flag evaluated", attributes...)
+}
+
+// loggerOrDefault returns logger, or the default logger if logger is nil.
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
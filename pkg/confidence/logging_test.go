@@ -0,0 +1,105 @@
+package confidence
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// capturingHandler records the log records written through it.
+type capturingHandler struct {
+	mu      *sync.Mutex
+	records *[]slog.Record
+}
+
+func newCapturingHandler() capturingHandler {
+	return capturingHandler{mu: &sync.Mutex{}, records: &[]slog.Record{}}
+}
+
+func (h capturingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h capturingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, record.Clone())
+	return nil
+}
+
+func (h capturingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h capturingHandler) WithGroup(string) slog.Handler { return h }
+
+// attributes returns the attributes of the first record with message.
+func (h capturingHandler) attributes(message string) map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, record := range *h.records {
+		if record.Message == message {
+			attributes := map[string]slog.Value{}
+			record.Attrs(func(attr slog.Attr) bool {
+				attributes[attr.Key] = attr.Value
+				return true
+			})
+			return attributes
+		}
+	}
+	return nil
+}
+
+func TestEveryComponentLogsThroughTheInjectedLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(templateResponse())
+	}))
+	defer server.Close()
+	handler := newCapturingHandler()
+	confidence := NewConfidenceBuilder().
+		SetAPIConfig(APIConfig{APIKey: "apiKey", APIResolveBaseUrl: server.URL}).
+		SetLogger(slog.New(handler)).
+		Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+	confidence.GetStringFlag(context.Background(), "test-flag.boolean-key", "default")
+	assert.NoError(t, confidence.Close(context.Background()))
+	confidence.Track(context.Background(), "purchase", map[string]interface{}{})
+
+	schema := map[string]bool{LogKeyFlag: true, LogKeyVariant: true, LogKeyReason: true, LogKeyErrorCode: true,
+		LogKeyLatencyMs: true, LogKeyError: true, LogKeyEvent: true, LogKeyStatus: true, LogKeyHTTPStatus: true,
+		LogKeyConfig: true}
+	for _, record := range *handler.records {
+		record.Attrs(func(attr slog.Attr) bool {
+			assert.True(t, schema[attr.Key], "unexpected attribute %s in %q", attr.Key, record.Message)
+			return true
+		})
+	}
+
+	request := handler.attributes("Resolve request completed")
+	assert.Equal(t, "flags/test-flag", request[LogKeyFlag].String())
+	assert.Equal(t, "success", request[LogKeyStatus].String())
+	assert.Contains(t, request, LogKeyLatencyMs)
+
+	evaluation := handler.attributes("Flag evaluated")
+	assert.Equal(t, "test-flag.boolean-key", evaluation[LogKeyFlag].String())
+	assert.Equal(t, "flags/test-flag/variants/treatment", evaluation[LogKeyVariant].String())
+	assert.Equal(t, string(TargetingMatchReason), evaluation[LogKeyReason].String())
+	assert.NotContains(t, evaluation, LogKeyErrorCode)
+
+	var mismatch map[string]slog.Value
+	for _, record := range *handler.records {
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == LogKeyErrorCode {
+				mismatch = map[string]slog.Value{attr.Key: attr.Value}
+			}
+			return true
+		})
+	}
+	assert.Equal(t, string(TypeMismatchCode), mismatch[LogKeyErrorCode].String())
+
+	assert.Equal(t, "purchase", handler.attributes("Event dropped, Confidence is closed")[LogKeyEvent].String())
+}
@@ -59,18 +59,43 @@ func NewGeneralResolutionError(msg string) ResolutionError {
 
 const DefaultAPIResolveBaseUrl = "https://resolver.confidence.dev"
 
+const DefaultAPIEventsBaseUrl = "https://events.eu.confidence.dev"
+
+// DefaultCircuitBreakerThreshold and DefaultCircuitBreakerCooldown are suggested settings for WithCircuitBreaker, the
+// circuit breaker is disabled unless it is configured.
+const (
+	DefaultCircuitBreakerThreshold = 5
+	DefaultCircuitBreakerCooldown  = 30 * time.Second
+)
+
 type APIConfig struct {
 	APIKey            string
 	APIResolveBaseUrl string
-	ResolveTimeout    time.Duration
-	EventTimeout      time.Duration
-	DisableTelemetry  bool
+	// APIEventsBaseUrl is the base URL of the events service tracked events are published to, it defaults to
+	// DefaultAPIEventsBaseUrl.
+	APIEventsBaseUrl string
+	ResolveTimeout   time.Duration
+	EventTimeout     time.Duration
+	DisableTelemetry bool
+	// CacheTTL is how long resolved flags are cached per evaluation context, zero disables the cache.
+	CacheTTL time.Duration
+	// CircuitBreakerThreshold is the number of consecutive failed resolves that opens the circuit to the resolver,
+	// zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is how long the circuit stays open before the resolver is tried again.
+	CircuitBreakerCooldown time.Duration
+	// Redaction selects the evaluation context attributes scrubbed from logs and evaluation listeners.
+	Redaction Redaction
+	// EnableResolveTesterHint logs, at debug level, a payload to paste in the Resolve tester for every resolved
+	// flag. The payload holds the client secret and the evaluation context, after Redaction.
+	EnableResolveTesterHint bool
 }
 
 func NewAPIConfig(apiKey string) *APIConfig {
 	return &APIConfig{
 		APIKey:            apiKey,
 		APIResolveBaseUrl: DefaultAPIResolveBaseUrl,
+		APIEventsBaseUrl:  DefaultAPIEventsBaseUrl,
 		ResolveTimeout:    10000 * time.Millisecond,
 		EventTimeout:      10000 * time.Millisecond,
 		DisableTelemetry:  false,
@@ -81,6 +106,7 @@ func NewAPIConfigWithUrl(apiKey, APIResolveBaseUrl string) *APIConfig {
 	return &APIConfig{
 		APIKey:            apiKey,
 		APIResolveBaseUrl: APIResolveBaseUrl,
+		APIEventsBaseUrl:  DefaultAPIEventsBaseUrl,
 		ResolveTimeout:    10000 * time.Millisecond,
 		EventTimeout:      10000 * time.Millisecond,
 		DisableTelemetry:  false,
@@ -92,6 +118,23 @@ func (c *APIConfig) WithResolveTimeout(timeout time.Duration) *APIConfig {
 	return c
 }
 
+func (c *APIConfig) WithEventsBaseUrl(url string) *APIConfig {
+	c.APIEventsBaseUrl = url
+	return c
+}
+
+func (c *APIConfig) WithCacheTTL(ttl time.Duration) *APIConfig {
+	c.CacheTTL = ttl
+	return c
+}
+
+// WithCircuitBreaker opens the circuit to the resolver after threshold consecutive failed resolves, for cooldown.
+func (c *APIConfig) WithCircuitBreaker(threshold int, cooldown time.Duration) *APIConfig {
+	c.CircuitBreakerThreshold = threshold
+	c.CircuitBreakerCooldown = cooldown
+	return c
+}
+
 func (c APIConfig) Validate() error {
 	if c.APIKey == "" {
 		return errors.New("api key needs to be set")
@@ -124,6 +167,13 @@ type ResolveRequest struct {
 	EvaluationContext map[string]interface{} `json:"evaluation_context"`
 	Flags             []string               `json:"flags"`
 	Sdk               sdk                    `json:"sdk"`
+	// library is the library the telemetry of the request is attributed to.
+	library ProtoLibraryTraces_ProtoLibrary
+}
+
+// sdkInfo returns the library the telemetry of the request is attributed to.
+func (r ResolveRequest) sdkInfo() SdkInfo {
+	return SdkInfo{Id: r.Sdk.Id, Version: r.Sdk.Version, Library: r.library}
 }
 
 type sdk struct {
@@ -131,11 +181,50 @@ type sdk struct {
 	Version string `json:"version"`
 }
 
+// SdkInfo identifies the library that resolves flags and tracks events through a Confidence, such as the
+// OpenFeature provider. It is sent with resolves and events, and attributes the telemetry of the resolves.
+type SdkInfo struct {
+	Id      string
+	Version string
+	Library ProtoLibraryTraces_ProtoLibrary
+	// Domain is the OpenFeature domain the library is bound to, if any. It isn't sent to Confidence, it tags the log
+	// records and the flag metadata of the evaluations, which the otel and prometheus packages report it from.
+	Domain string
+}
+
+func defaultSdkInfo() SdkInfo {
+	return SdkInfo{Id: SDK_ID, Version: SDK_VERSION, Library: ProtoLibraryTraces_PROTO_LIBRARY_CONFIDENCE}
+}
+
 type ResolveResponse struct {
 	ResolvedFlags []resolvedFlag `json:"resolvedFlags"`
 	ResolveToken  string         `json:"resolveToken"`
+	// Source tells where the response came from. Resolve clients that don't call the resolver service should set it,
+	// it defaults to ResolveSourceNetwork.
+	Source ResolveSource `json:"-"`
 }
 
+func (r ResolveResponse) source() ResolveSource {
+	if r.Source == "" {
+		return ResolveSourceNetwork
+	}
+	return r.Source
+}
+
+// ResolveSource tells where resolved flag values came from.
+type ResolveSource string
+
+const (
+	// ResolveSourceNetwork - the values were resolved by the resolver service.
+	ResolveSourceNetwork ResolveSource = "network"
+	// ResolveSourceCache - the values were served from a cache of earlier resolves.
+	ResolveSourceCache ResolveSource = "cache"
+	// ResolveSourceLocal - the values were resolved in process, without calling the resolver service.
+	ResolveSourceLocal ResolveSource = "local"
+	// ResolveSourceOverride - the values were set locally as overrides, see Confidence.SetOverride.
+	ResolveSourceOverride ResolveSource = "override"
+)
+
 type resolveErrorMessage struct {
 	Code    int64  `json:"code"`
 	Message string `json:"message"`
@@ -48,3 +48,23 @@ func TestAPIConfig_WithResolveTimeout_MultipleUpdates(t *testing.T) {
 		t.Errorf("Expected ResolveTimeout to be %v after second update, got %v", secondTimeout, config.ResolveTimeout)
 	}
 }
+
+func TestAPIConfig_WithCircuitBreaker(t *testing.T) {
+	config := NewAPIConfig("test-key")
+
+	// Verify the circuit breaker is disabled by default
+	if config.CircuitBreakerThreshold != 0 {
+		t.Errorf("Expected default CircuitBreakerThreshold to be 0, got %v", config.CircuitBreakerThreshold)
+	}
+
+	config.WithCircuitBreaker(DefaultCircuitBreakerThreshold, DefaultCircuitBreakerCooldown)
+
+	if config.CircuitBreakerThreshold != DefaultCircuitBreakerThreshold {
+		t.Errorf("Expected CircuitBreakerThreshold to be %v, got %v",
+			DefaultCircuitBreakerThreshold, config.CircuitBreakerThreshold)
+	}
+	if config.CircuitBreakerCooldown != DefaultCircuitBreakerCooldown {
+		t.Errorf("Expected CircuitBreakerCooldown to be %v, got %v",
+			DefaultCircuitBreakerCooldown, config.CircuitBreakerCooldown)
+	}
+}
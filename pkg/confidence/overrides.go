@@ -0,0 +1,433 @@
+package confidence
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OverridesEnvVar is the environment variable read for flag overrides, a JSON object of flag references to values,
+// e.g. {"my-flag.enabled": true}.
+const OverridesEnvVar = "CONFIDENCE_FLAG_OVERRIDES"
+
+// DefaultOverridesPollInterval is how often an overrides file is checked for changes by default.
+const DefaultOverridesPollInterval = time.Second
+
+// Origins of an override, reported in FlagMetadataOverride.
+const (
+	OverrideOriginCode = "code"
+	OverrideOriginEnv  = "env"
+	OverrideOriginFile = "file"
+)
+
+// overridesEnabled tells whether overrides are honored, see the confidence_overrides build tag.
+var overridesEnabled = overridesBuildTag
+
+var errOverridesDisabled = errors.New("flag overrides are disabled, build with -tags confidence_overrides")
+
+// override forces the value of a flag, or of a property within it, without resolving the flag.
+type override struct {
+	flag   string
+	path   propertyPath
+	value  interface{}
+	schema map[string]interface{}
+	origin string
+}
+
+// covers reports whether the override sets the property at path of flag, that is whether it overrides path or one
+// of its parents.
+func (o override) covers(flag string, path propertyPath) bool {
+	return len(o.path.steps) <= len(path.steps) && o.appliesTo(flag, path)
+}
+
+// appliesTo reports whether the override sets the property at path of flag or a property within it, that is whether
+// one of their paths is a prefix of the other.
+func (o override) appliesTo(flag string, path propertyPath) bool {
+	if o.flag != flag {
+		return false
+	}
+	for i := 0; i < len(o.path.steps) && i < len(path.steps); i++ {
+		if path.steps[i].isIndex || path.steps[i].key != o.path.steps[i].key {
+			return false
+		}
+	}
+	return true
+}
+
+// applyOverrides returns a copy of flag with the value and schema of every override in overrides set, in order, so
+// that later overrides replace the properties set by earlier ones.
+func applyOverrides(flag resolvedFlag, overrides []override) resolvedFlag {
+	value := copyStruct(flag.Value)
+	fields := copyStruct(flag.FlagSchema.Schema)
+	for _, o := range overrides {
+		if o.path.isEmpty() {
+			value = copyStruct(o.value.(map[string]interface{}))
+			fields = copyStruct(structFields(o.schema))
+			continue
+		}
+		values, schema := value, fields
+		last := len(o.path.steps) - 1
+		for _, step := range o.path.steps[:last] {
+			child, isStruct := values[step.key].(map[string]interface{})
+			childSchema, _ := schema[step.key].(map[string]interface{})
+			childFields := structFields(childSchema)
+			if !isStruct || childFields == nil {
+				child, childFields = map[string]interface{}{}, map[string]interface{}{}
+				schema[step.key] = map[string]interface{}{"structSchema": map[string]interface{}{"schema": childFields}}
+				values[step.key] = child
+			}
+			values, schema = child, childFields
+		}
+		key := o.path.steps[last].key
+		values[key] = copyValue(o.value)
+		schema[key] = copyValue(o.schema)
+	}
+	flag.Value, flag.FlagSchema = value, flagSchema{Schema: fields}
+	return flag
+}
+
+// copyStruct returns a copy of value, with the nested structs copied too. A nil struct is copied as an empty one.
+func copyStruct(value map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(value))
+	for key, child := range value {
+		copied[key] = copyValue(child)
+	}
+	return copied
+}
+
+// copyValue returns a copy of value if it is a struct, and value otherwise.
+func copyValue(value interface{}) interface{} {
+	if nested, ok := value.(map[string]interface{}); ok {
+		return copyStruct(nested)
+	}
+	return value
+}
+
+// overrideStore holds the overrides of a Confidence and its children, by origin. A nil store holds no overrides.
+type overrideStore struct {
+	mu     sync.RWMutex
+	code   map[string]override
+	env    map[string]override
+	file   map[string]override
+	stop   chan struct{}
+	closed sync.Once
+}
+
+func newOverrideStore() *overrideStore {
+	return &overrideStore{code: map[string]override{}, env: map[string]override{}, file: map[string]override{},
+		stop: make(chan struct{})}
+}
+
+// lookup returns the overrides of the property at path of flag and of the properties within it, in the order they
+// are applied, and whether one of them sets the whole property so that the flag doesn't need to be resolved.
+// Overrides set in code take precedence over the environment, which takes precedence over the file, and within an
+// origin more specific overrides take precedence.
+func (s *overrideStore) lookup(flag string, path propertyPath) ([]override, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found []override
+	covered := false
+	for _, overrides := range []map[string]override{s.file, s.env, s.code} {
+		var origin []override
+		for _, candidate := range overrides {
+			if candidate.appliesTo(flag, path) {
+				origin = append(origin, candidate)
+				covered = covered || candidate.covers(flag, path)
+			}
+		}
+		sort.Slice(origin, func(i, j int) bool { return len(origin[i].path.steps) < len(origin[j].path.steps) })
+		found = append(found, origin...)
+	}
+	return found, covered
+}
+
+// set replaces the overrides of origin with overrides, or adds them if add is true.
+func (s *overrideStore) set(origin string, overrides map[string]override, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target := map[string]override{}
+	if add {
+		for key, existing := range s.byOrigin(origin) {
+			target[key] = existing
+		}
+	}
+	for key, value := range overrides {
+		target[key] = value
+	}
+	switch origin {
+	case OverrideOriginCode:
+		s.code = target
+	case OverrideOriginEnv:
+		s.env = target
+	case OverrideOriginFile:
+		s.file = target
+	}
+}
+
+func (s *overrideStore) byOrigin(origin string) map[string]override {
+	switch origin {
+	case OverrideOriginCode:
+		return s.code
+	case OverrideOriginEnv:
+		return s.env
+	}
+	return s.file
+}
+
+// remove removes the override of flag set in code, if any.
+func (s *overrideStore) remove(flag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.code, flag)
+}
+
+// count returns the number of overrides, across origins.
+func (s *overrideStore) count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.code) + len(s.env) + len(s.file)
+}
+
+// close stops watching the overrides file.
+func (s *overrideStore) close() {
+	if s == nil {
+		return
+	}
+	s.closed.Do(func() { close(s.stop) })
+}
+
+// watch loads the overrides file at path every interval if it changed, until the store is closed. Loading errors
+// are reported to onError, the overrides loaded last are kept.
+func (s *overrideStore) watch(path string, interval time.Duration, onError func(error)) {
+	var lastModified time.Time
+	var lastSize int64 = -1
+	load := func() {
+		info, err := os.Stat(path)
+		if err != nil {
+			onError(err)
+			return
+		}
+		if info.ModTime().Equal(lastModified) && info.Size() == lastSize {
+			return
+		}
+		lastModified, lastSize = info.ModTime(), info.Size()
+		overrides, err := readOverridesFile(path)
+		if err != nil {
+			onError(err)
+			return
+		}
+		s.set(OverrideOriginFile, overrides, false)
+	}
+	load()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				load()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// readOverridesFile reads the overrides of a JSON file, if path ends with .json, or of a YAML file. YAML files are only
+// supported with the confidence_overrides build tag.
+func readOverridesFile(path string) (map[string]override, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	} else {
+		values, err = unmarshalYAMLOverrides(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid overrides file %s: %w", path, err)
+	}
+	return parseOverrides(values, OverrideOriginFile)
+}
+
+// readOverridesEnv reads the overrides of OverridesEnvVar, if it is set.
+func readOverridesEnv() (map[string]override, error) {
+	content, ok := os.LookupEnv(OverridesEnvVar)
+	if !ok || strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+	var values map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", OverridesEnvVar, err)
+	}
+	return parseOverrides(values, OverrideOriginEnv)
+}
+
+// parseOverrides returns the overrides of values, by flag reference.
+func parseOverrides(values map[string]interface{}, origin string) (map[string]override, error) {
+	overrides := map[string]override{}
+	for reference, value := range values {
+		parsed, err := newOverride(reference, value, origin)
+		if err != nil {
+			return nil, err
+		}
+		overrides[reference] = parsed
+	}
+	return overrides, nil
+}
+
+// newOverride returns the override of the flag or property referenced by reference with value.
+func newOverride(reference string, value interface{}, origin string) (override, error) {
+	flag, path, err := parseFlagPath(reference)
+	if err != nil {
+		return override{}, err
+	}
+	for _, step := range path.steps {
+		if step.isIndex {
+			return override{}, fmt.Errorf("invalid override %q: list elements can't be overridden", reference)
+		}
+	}
+	normalized, schema, err := overrideValue(reflect.ValueOf(value), reference)
+	if err != nil {
+		return override{}, fmt.Errorf("invalid override %q: %w", reference, err)
+	}
+	if _, isStruct := normalized.(map[string]interface{}); path.isEmpty() && !isStruct {
+		return override{}, fmt.Errorf("invalid override %q: the value of a whole flag must be an object", reference)
+	}
+	return override{flag: flag, path: path, value: normalized, schema: schema, origin: origin}, nil
+}
+
+// overrideValue returns value in the form of a resolved flag value, with numbers as json.Number, together with its
+// inferred property schema. Integers are inferred as ints, other numbers as doubles.
+func overrideValue(value reflect.Value, path string) (interface{}, map[string]interface{}, error) {
+	for !value.IsValid() || value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer {
+		if !value.IsValid() || value.IsNil() {
+			return nil, nil, fmt.Errorf("the type of the null value of %s can't be inferred", path)
+		}
+		value = value.Elem()
+	}
+	if number, ok := value.Interface().(json.Number); ok {
+		if strings.ContainsAny(string(number), ".eE") {
+			return number, map[string]interface{}{"doubleSchema": map[string]interface{}{}}, nil
+		}
+		return number, map[string]interface{}{"intSchema": map[string]interface{}{}}, nil
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool(), map[string]interface{}{"boolSchema": map[string]interface{}{}}, nil
+	case reflect.String:
+		return value.String(), map[string]interface{}{"stringSchema": map[string]interface{}{}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(value.Int(), 10)),
+			map[string]interface{}{"intSchema": map[string]interface{}{}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return json.Number(strconv.FormatUint(value.Uint(), 10)),
+			map[string]interface{}{"intSchema": map[string]interface{}{}}, nil
+	case reflect.Float32, reflect.Float64:
+		return json.Number(strconv.FormatFloat(value.Float(), 'g', -1, 64)),
+			map[string]interface{}{"doubleSchema": map[string]interface{}{}}, nil
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, nil, fmt.Errorf("%s is a map without string keys", path)
+		}
+		fields := map[string]interface{}{}
+		normalized := map[string]interface{}{}
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			child, schema, err := overrideValue(value.MapIndex(key), path+"."+key.String())
+			if err != nil {
+				return nil, nil, err
+			}
+			normalized[key.String()], fields[key.String()] = child, schema
+		}
+		return normalized, map[string]interface{}{"structSchema": map[string]interface{}{"schema": fields}}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]interface{}, value.Len())
+		elementSchema := map[string]interface{}{}
+		for i := 0; i < value.Len(); i++ {
+			element, schema, err := overrideValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, nil, err
+			}
+			if i > 0 && !reflect.DeepEqual(schema, elementSchema) {
+				return nil, nil, fmt.Errorf("the elements of %s have different types", path)
+			}
+			elements[i], elementSchema = element, schema
+		}
+		return elements, map[string]interface{}{"listSchema": map[string]interface{}{"elementSchema": elementSchema}}, nil
+	}
+	return nil, nil, fmt.Errorf("%s has the unsupported type %s", path, value.Type())
+}
+
+// SetOverride forces the value of the flag, or of the property within it, referenced by flag, e.g. "my-flag.enabled",
+// for this Confidence and every Confidence sharing its configuration. Overridden flags are evaluated without being
+// resolved, with the reason STATIC. It fails if overrides are disabled, see the confidence_overrides build tag.
+func (e Confidence) SetOverride(flag string, value interface{}) error {
+	if !e.built() {
+		return errNotBuilt
+	}
+	if e.overrides == nil {
+		return errOverridesDisabled
+	}
+	parsed, err := newOverride(flag, value, OverrideOriginCode)
+	if err != nil {
+		return err
+	}
+	e.overrides.set(OverrideOriginCode, map[string]override{flag: parsed}, true)
+	return nil
+}
+
+// ClearOverride removes the override set with SetOverride for flag.
+func (e Confidence) ClearOverride(flag string) {
+	if e.built() && e.overrides != nil {
+		e.overrides.remove(flag)
+	}
+}
+
+// setUpOverrides reads the overrides of the environment and of the overrides file, if overrides are enabled.
+func (core *confidenceCore) setUpOverrides(file string, pollInterval time.Duration) {
+	if !overridesEnabled {
+		if file != "" {
+			core.Logger.Warn("Ignoring the overrides file, flag overrides are disabled", LogKeyError,
+				errOverridesDisabled)
+		}
+		return
+	}
+	core.overrides = newOverrideStore()
+	if env, err := readOverridesEnv(); err != nil {
+		core.Logger.Warn("Failed to load flag overrides", LogKeyError, err)
+	} else {
+		core.overrides.set(OverrideOriginEnv, env, false)
+	}
+	if file != "" {
+		if pollInterval <= 0 {
+			pollInterval = DefaultOverridesPollInterval
+		}
+		core.overrides.watch(file, pollInterval, func(err error) {
+			core.Logger.Warn("Failed to load flag overrides", LogKeyError, err)
+		})
+	}
+	if core.overrides.count() > 0 || file != "" {
+		core.Logger.Warn("Flag overrides are enabled, overridden flags are not resolved")
+	}
+}
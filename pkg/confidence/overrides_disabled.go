@@ -0,0 +1,11 @@
+//go:build !confidence_overrides
+
+package confidence
+
+// overridesBuildTag disables flag overrides, the SDK is built without the confidence_overrides build tag.
+const overridesBuildTag = false
+
+// unmarshalYAMLOverrides fails, YAML overrides files need the confidence_overrides build tag.
+func unmarshalYAMLOverrides(_ []byte) (map[string]interface{}, error) {
+	return nil, errOverridesDisabled
+}
@@ -0,0 +1,16 @@
+//go:build confidence_overrides
+
+package confidence
+
+import "gopkg.in/yaml.v3"
+
+// overridesBuildTag enables flag overrides, the SDK is built with the confidence_overrides build tag.
+const overridesBuildTag = true
+
+// unmarshalYAMLOverrides decodes the content of a YAML overrides file. The YAML decoder is only linked into binaries
+// built with the confidence_overrides build tag.
+func unmarshalYAMLOverrides(content []byte) (map[string]interface{}, error) {
+	var values map[string]interface{}
+	err := yaml.Unmarshal(content, &values)
+	return values, err
+}
@@ -0,0 +1,22 @@
+//go:build confidence_overrides
+
+package confidence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYamlOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("test-flag.double-key: 1.5\ntest-flag.integer-key: 2\n"), 0o644))
+	confidence, _ := newOverridingConfidence(NewConfidenceBuilder().SetOverridesFile(path, 0))
+	defer confidence.Close(context.Background())
+
+	assert.Equal(t, 1.5, confidence.GetDoubleValue(context.Background(), "test-flag.double-key", 0))
+	assert.Equal(t, int64(2), confidence.GetIntValue(context.Background(), "test-flag.integer-key", 0))
+}
@@ -0,0 +1,177 @@
+package confidence
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// enableOverrides enables overrides for the test, as the confidence_overrides build tag does.
+func enableOverrides(t *testing.T) {
+	enabled := overridesEnabled
+	overridesEnabled = true
+	t.Cleanup(func() { overridesEnabled = enabled })
+}
+
+func newOverridingConfidence(builder ConfidenceBuilder) (Confidence, *sequenceResolveClient) {
+	client := &sequenceResolveClient{responses: []ResolveResponse{templateResponse()}, errors: []error{nil}}
+	confidence := builder.
+		SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(client).
+		Build()
+	confidence.PutContext("targeting_key", "user1")
+	return confidence, client
+}
+
+func TestOverridesAreEvaluatedWithoutResolving(t *testing.T) {
+	enableOverrides(t)
+	confidence, client := newOverridingConfidence(NewConfidenceBuilder())
+
+	assert.NoError(t, confidence.SetOverride("test-flag.integer-key", 7))
+	assert.NoError(t, confidence.SetOverride("other-flag", map[string]interface{}{
+		"enabled": true, "ratio": 0.25, "tags": []string{"a", "b"}, "limits": map[string]interface{}{"daily": 3}}))
+
+	detail := confidence.GetIntFlag(context.Background(), "test-flag.integer-key", 0)
+	assert.Equal(t, int64(7), detail.Value)
+	assert.Equal(t, StaticReason, detail.Reason)
+	assert.Equal(t, "override", detail.Variant)
+	assert.Equal(t, string(ResolveSourceOverride), detail.FlagMetadata[FlagMetadataSource])
+	assert.Equal(t, OverrideOriginCode, detail.FlagMetadata[FlagMetadataOverride])
+	assert.Equal(t, "int", detail.FlagMetadata[FlagMetadataSchemaType])
+
+	assert.True(t, confidence.GetBoolValue(context.Background(), "other-flag.enabled", false))
+	assert.Equal(t, 0.25, confidence.GetDoubleValue(context.Background(), "other-flag.ratio", 0))
+	assert.Equal(t, []string{"a", "b"}, confidence.GetStringListValue(context.Background(), "other-flag.tags", nil))
+	assert.Equal(t, int64(3), confidence.GetIntValue(context.Background(), "other-flag.limits.daily", 0))
+	assert.Equal(t, TypeMismatchCode, confidence.GetStringFlag(context.Background(), "other-flag.ratio", "").ErrorCode)
+	assert.Equal(t, 0, client.calls)
+
+	detail = confidence.WithContext(map[string]interface{}{"country": "SE"}).
+		GetIntFlag(context.Background(), "test-flag.integer-key", 0)
+	assert.Equal(t, int64(7), detail.Value)
+
+	assert.Equal(t, TargetingMatchReason,
+		confidence.GetStringFlag(context.Background(), "test-flag.struct-key.string-key", "").Reason)
+	assert.Equal(t, 1, client.calls)
+
+	confidence.ClearOverride("test-flag.integer-key")
+	assert.Equal(t, int64(40), confidence.GetIntValue(context.Background(), "test-flag.integer-key", 0))
+}
+
+func TestMostSpecificOverrideOfTheHighestPrecedenceWins(t *testing.T) {
+	enableOverrides(t)
+	t.Setenv(OverridesEnvVar, `{"test-flag.struct-key": {"string-key": "env", "boolean-key": true}}`)
+	confidence, _ := newOverridingConfidence(NewConfidenceBuilder())
+
+	detail := confidence.GetStringFlag(context.Background(), "test-flag.struct-key.string-key", "")
+	assert.Equal(t, "env", detail.Value)
+	assert.Equal(t, OverrideOriginEnv, detail.FlagMetadata[FlagMetadataOverride])
+
+	assert.NoError(t, confidence.SetOverride("test-flag.struct-key.string-key", "code"))
+	assert.Equal(t, "code", confidence.GetStringValue(context.Background(), "test-flag.struct-key.string-key", ""))
+	assert.True(t, confidence.GetBoolValue(context.Background(), "test-flag.struct-key.boolean-key", false))
+}
+
+func TestOverridesFileIsReloaded(t *testing.T) {
+	enableOverrides(t)
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"test-flag.integer-key": 5}`), 0o644))
+	handler := newCapturingHandler()
+	confidence, _ := newOverridingConfidence(NewConfidenceBuilder().
+		SetLogger(slog.New(handler)).
+		SetOverridesFile(path, 10*time.Millisecond))
+	defer confidence.Close(context.Background())
+
+	detail := confidence.GetIntFlag(context.Background(), "test-flag.integer-key", 0)
+	assert.Equal(t, int64(5), detail.Value)
+	assert.Equal(t, OverrideOriginFile, detail.FlagMetadata[FlagMetadataOverride])
+	assert.NotNil(t, handler.attributes("Flag overrides are enabled, overridden flags are not resolved"))
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"test-flag.integer-key": 6}`), 0o644))
+	assert.Eventually(t, func() bool {
+		return confidence.GetIntValue(context.Background(), "test-flag.integer-key", 0) == 6
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"test-flag.integer-key": [1, `), 0o644))
+	assert.Eventually(t, func() bool {
+		return handler.attributes("Failed to load flag overrides") != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(6), confidence.GetIntValue(context.Background(), "test-flag.integer-key", 0))
+}
+
+func TestJsonOverridesFile(t *testing.T) {
+	enableOverrides(t)
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"test-flag.double-key": 1.5, "test-flag.integer-key": 2}`), 0o644))
+	confidence, _ := newOverridingConfidence(NewConfidenceBuilder().SetOverridesFile(path, 0))
+	defer confidence.Close(context.Background())
+
+	assert.Equal(t, 1.5, confidence.GetDoubleValue(context.Background(), "test-flag.double-key", 0))
+	assert.Equal(t, int64(2), confidence.GetIntValue(context.Background(), "test-flag.integer-key", 0))
+}
+
+func TestInvalidOverrides(t *testing.T) {
+	enableOverrides(t)
+	confidence, _ := newOverridingConfidence(NewConfidenceBuilder())
+
+	assert.Error(t, confidence.SetOverride("test-flag", true))
+	assert.Error(t, confidence.SetOverride("test-flag.list[0]", true))
+	assert.Error(t, confidence.SetOverride("test-flag.key", nil))
+	assert.Error(t, confidence.SetOverride("test-flag.key", []interface{}{1, "a"}))
+	assert.Error(t, confidence.SetOverride("", true))
+}
+
+func TestOverridesAreDisabledByDefault(t *testing.T) {
+	enabled := overridesEnabled
+	overridesEnabled = false
+	t.Cleanup(func() { overridesEnabled = enabled })
+	t.Setenv(OverridesEnvVar, `{"test-flag.integer-key": 7}`)
+	confidence, client := newOverridingConfidence(NewConfidenceBuilder())
+
+	assert.ErrorIs(t, confidence.SetOverride("test-flag.integer-key", 7), errOverridesDisabled)
+	detail := confidence.GetIntFlag(context.Background(), "test-flag.integer-key", 0)
+	assert.Equal(t, int64(40), detail.Value)
+	assert.Equal(t, TargetingMatchReason, detail.Reason)
+	assert.Equal(t, 1, client.calls)
+}
+
+func TestDeeperOverridesAreMergedIntoTheEvaluatedProperty(t *testing.T) {
+	enableOverrides(t)
+	confidence, client := newOverridingConfidence(NewConfidenceBuilder())
+
+	assert.NoError(t, confidence.SetOverride("other-flag.limits", map[string]interface{}{"daily": 3, "weekly": 10}))
+	assert.NoError(t, confidence.SetOverride("other-flag.limits.daily", 5))
+	limits := confidence.GetObjectFlag(context.Background(), "other-flag.limits", nil)
+	assert.Equal(t, map[string]interface{}{"daily": int64(5), "weekly": int64(10)}, limits.Value)
+	assert.Equal(t, StaticReason, limits.Reason)
+	assert.Equal(t, 0, client.calls)
+
+	assert.NoError(t, confidence.SetOverride("test-flag.struct-key.string-key", "code"))
+	detail := confidence.GetObjectFlag(context.Background(), "test-flag.struct-key", nil)
+	structValue := detail.Value.(map[string]interface{})
+	assert.Equal(t, "code", structValue["string-key"])
+	assert.Equal(t, false, structValue["boolean-key"])
+	assert.Equal(t, TargetingMatchReason, detail.Reason)
+	assert.Equal(t, OverrideOriginCode, detail.FlagMetadata[FlagMetadataOverride])
+	assert.Equal(t, 1, client.calls)
+
+	confidence.ClearOverride("test-flag.struct-key.string-key")
+	assert.Equal(t, "treatment-struct",
+		confidence.GetStringValue(context.Background(), "test-flag.struct-key.string-key", ""))
+}
+
+func TestYamlOverridesFileNeedsTheBuildTag(t *testing.T) {
+	if overridesBuildTag {
+		t.Skip("YAML overrides files are supported with the confidence_overrides build tag")
+	}
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("test-flag.integer-key: 5\n"), 0o644))
+
+	_, err := readOverridesFile(path)
+	assert.ErrorIs(t, err, errOverridesDisabled)
+}
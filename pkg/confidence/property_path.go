@@ -0,0 +1,288 @@
+package confidence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A flag reference is the flag name followed by an optional path to a property within the flag value.
+// The path is written either in dot notation or as a JSON pointer (RFC 6901):
+//
+//	my-flag                       the whole flag value
+//	my-flag.struct-key.bool-key   nested properties
+//	my-flag.items[2].name         an element of a list
+//	my-flag["key.with.dots"]      a quoted property, a quoted segment may also follow a dot: my-flag."key.with.dots"
+//	my-flag.key\.with\.dots       an escaped property, a backslash escapes the following character
+//	my-flag/items/2/name          a JSON pointer, where "~1" escapes "/" and "~0" escapes "~"
+//
+// In a JSON pointer a numeric segment addresses a list element when applied to a list and a property otherwise.
+
+// pathStep is one step of a property path: either a struct property or, for lists, an element index.
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+	// indexable is set for numeric JSON pointer segments, which address an element when applied to a list.
+	indexable bool
+}
+
+// listIndex returns the list index addressed by the step, if any.
+func (p pathStep) listIndex() (int, bool) {
+	return p.index, p.isIndex || p.indexable
+}
+
+func (p pathStep) String() string {
+	if p.isIndex {
+		return fmt.Sprintf("[%d]", p.index)
+	}
+	if needsQuoting(p.key) {
+		return "[" + strconv.Quote(p.key) + "]"
+	}
+	return p.key
+}
+
+// propertyPath is a compiled path to a property within a flag value. The zero value refers to the whole value.
+type propertyPath struct {
+	steps []pathStep
+}
+
+func (p propertyPath) isEmpty() bool {
+	return len(p.steps) == 0
+}
+
+// String renders the path in dot notation.
+func (p propertyPath) String() string {
+	var builder strings.Builder
+	for i, step := range p.steps {
+		rendered := step.String()
+		if i > 0 && !strings.HasPrefix(rendered, "[") {
+			builder.WriteString(".")
+		}
+		builder.WriteString(rendered)
+	}
+	return builder.String()
+}
+
+// describe names the property reached after the first n steps, for use in error messages.
+func (p propertyPath) describe(n int) string {
+	if n == 0 {
+		return "the flag value"
+	}
+	return "property " + propertyPath{steps: p.steps[:n]}.String()
+}
+
+// description names the property the path refers to, for use in error messages.
+func (p propertyPath) description() string {
+	return p.describe(len(p.steps))
+}
+
+func (p propertyPath) withKey(key string) propertyPath {
+	return p.with(pathStep{key: key})
+}
+
+func (p propertyPath) withIndex(index int) propertyPath {
+	return p.with(pathStep{index: index, isIndex: true})
+}
+
+func (p propertyPath) with(step pathStep) propertyPath {
+	steps := make([]pathStep, len(p.steps), len(p.steps)+1)
+	copy(steps, p.steps)
+	return propertyPath{steps: append(steps, step)}
+}
+
+func needsQuoting(key string) bool {
+	return key == "" || strings.ContainsAny(key, ".[]\"\\/")
+}
+
+// parseFlagPath splits a flag reference into the flag name and the compiled path to the requested property.
+func parseFlagPath(flag string) (string, propertyPath, error) {
+	end := strings.IndexAny(flag, ".[/")
+	if end < 0 {
+		return flag, propertyPath{}, nil
+	}
+	if end == 0 {
+		return "", propertyPath{}, fmt.Errorf("invalid flag reference %q: missing flag name", flag)
+	}
+
+	path, err := parsePropertyPath(flag[end:])
+	if err != nil {
+		return "", propertyPath{}, fmt.Errorf("invalid flag reference %q: %w", flag, err)
+	}
+	return flag[:end], path, nil
+}
+
+// parsePropertyPath compiles a property path in dot notation, optionally starting with a separator, or a JSON pointer.
+func parsePropertyPath(path string) (propertyPath, error) {
+	if strings.HasPrefix(path, "/") {
+		return parsePointer(path)
+	}
+
+	parser := pathParser{input: path}
+	return parser.parse()
+}
+
+func parsePointer(pointer string) (propertyPath, error) {
+	var steps []pathStep
+	for _, token := range strings.Split(pointer[1:], "/") {
+		if token == "" {
+			return propertyPath{}, fmt.Errorf("empty property name in %q", pointer)
+		}
+		key, err := unescapePointerToken(token)
+		if err != nil {
+			return propertyPath{}, fmt.Errorf("%w in %q", err, pointer)
+		}
+		step := pathStep{key: key}
+		if index, err := strconv.Atoi(key); err == nil && index >= 0 && strconv.Itoa(index) == key {
+			step.index = index
+			step.indexable = true
+		}
+		steps = append(steps, step)
+	}
+	return propertyPath{steps: steps}, nil
+}
+
+func unescapePointerToken(token string) (string, error) {
+	var builder strings.Builder
+	for i := 0; i < len(token); i++ {
+		if token[i] != '~' {
+			builder.WriteByte(token[i])
+			continue
+		}
+		if i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1') {
+			return "", fmt.Errorf("invalid escape sequence at offset %d", i)
+		}
+		if token[i+1] == '0' {
+			builder.WriteByte('~')
+		} else {
+			builder.WriteByte('/')
+		}
+		i++
+	}
+	return builder.String(), nil
+}
+
+type pathParser struct {
+	input string
+	pos   int
+	steps []pathStep
+}
+
+func (p *pathParser) parse() (propertyPath, error) {
+	if p.input != "" && p.input[0] != '.' && p.input[0] != '[' {
+		if err := p.parseSegment(); err != nil {
+			return propertyPath{}, err
+		}
+	}
+
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case '.':
+			p.pos++
+			if err := p.parseSegment(); err != nil {
+				return propertyPath{}, err
+			}
+		case '[':
+			if err := p.parseBracket(); err != nil {
+				return propertyPath{}, err
+			}
+		default:
+			return propertyPath{}, p.errorf("unexpected character %q", p.input[p.pos])
+		}
+	}
+
+	return propertyPath{steps: p.steps}, nil
+}
+
+// parseSegment parses a property name following a dot, either bare with backslash escapes or quoted.
+func (p *pathParser) parseSegment() error {
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		key, err := p.parseQuoted()
+		if err != nil {
+			return err
+		}
+		p.steps = append(p.steps, pathStep{key: key})
+		return nil
+	}
+
+	var builder strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '.' || c == '[' {
+			break
+		}
+		if c == '\\' {
+			if p.pos+1 == len(p.input) {
+				return p.errorf("dangling escape character")
+			}
+			p.pos++
+			c = p.input[p.pos]
+		}
+		builder.WriteByte(c)
+		p.pos++
+	}
+	if builder.Len() == 0 {
+		return p.errorf("empty property name")
+	}
+
+	p.steps = append(p.steps, pathStep{key: builder.String()})
+	return nil
+}
+
+// parseBracket parses either a list index, [2], or a quoted property name, ["name"].
+func (p *pathParser) parseBracket() error {
+	p.pos++
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		key, err := p.parseQuoted()
+		if err != nil {
+			return err
+		}
+		p.steps = append(p.steps, pathStep{key: key})
+	} else {
+		start := p.pos
+		for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.input[start:p.pos])
+		if err != nil {
+			return p.errorf("malformed list index")
+		}
+		p.steps = append(p.steps, pathStep{index: index, isIndex: true})
+	}
+
+	if p.pos == len(p.input) || p.input[p.pos] != ']' {
+		return p.errorf("expected ']'")
+	}
+	p.pos++
+	return nil
+}
+
+func (p *pathParser) parseQuoted() (string, error) {
+	var builder strings.Builder
+	p.pos++
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			if builder.Len() == 0 {
+				return "", p.errorf("empty property name")
+			}
+			return builder.String(), nil
+		case '\\':
+			if p.pos+1 == len(p.input) {
+				return "", p.errorf("dangling escape character")
+			}
+			p.pos++
+			c = p.input[p.pos]
+		}
+		builder.WriteByte(c)
+		p.pos++
+	}
+
+	return "", p.errorf("unterminated quoted property name")
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d in %q", fmt.Sprintf(format, args...), p.pos, p.input)
+}
@@ -0,0 +1,83 @@
+package confidence
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// redactedValue replaces the values of redacted context attributes.
+const redactedValue = "[REDACTED]"
+
+// Redaction selects the evaluation context attributes scrubbed from logs and evaluation listeners, their values are
+// replaced with "[REDACTED]". The zero value keeps every attribute.
+type Redaction struct {
+	// AllowKeys lists the only top level attributes that are kept, together with their nested attributes. All
+	// attributes are kept if it is empty.
+	AllowKeys []string
+	// DenyKeys lists the attributes that are redacted, at any depth.
+	DenyKeys []string
+}
+
+// Apply returns a copy of evaluationContext with the attributes selected by r redacted. evaluationContext itself is
+// not modified, and nested attributes are copied too even when nothing is redacted.
+func (r Redaction) Apply(evaluationContext map[string]interface{}) map[string]interface{} {
+	if evaluationContext == nil {
+		return nil
+	}
+	redacted := redactKeys(evaluationContext, toSet(r.DenyKeys))
+	if len(r.AllowKeys) > 0 {
+		allowed := toSet(r.AllowKeys)
+		for key := range redacted {
+			if !allowed[key] {
+				redacted[key] = redactedValue
+			}
+		}
+	}
+	return redacted
+}
+
+// redactKeys returns a copy of value with the values of keys replaced, at any depth.
+func redactKeys(value map[string]interface{}, keys map[string]bool) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(value))
+	for key, child := range value {
+		if keys[key] {
+			redacted[key] = redactedValue
+		} else if nested, ok := child.(map[string]interface{}); ok {
+			redacted[key] = redactKeys(nested, keys)
+		} else {
+			redacted[key] = child
+		}
+	}
+	return redacted
+}
+
+func toSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// maskSecret hides secret, keeping its last characters when it is long enough to still be told apart from others.
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// loggedAPIConfig is an APIConfig without the LogValue and String methods, so that a masked copy can be logged.
+type loggedAPIConfig APIConfig
+
+// LogValue masks the API key when the config is logged.
+func (c APIConfig) LogValue() slog.Value {
+	c.APIKey = maskSecret(c.APIKey)
+	return slog.AnyValue(loggedAPIConfig(c))
+}
+
+// String masks the API key when the config is printed.
+func (c APIConfig) String() string {
+	c.APIKey = maskSecret(c.APIKey)
+	return fmt.Sprintf("%+v", loggedAPIConfig(c))
+}
@@ -0,0 +1,107 @@
+package confidence
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactionScrubsDeniedAndUnlistedKeys(t *testing.T) {
+	evaluationContext := map[string]interface{}{
+		"targeting_key": "user1",
+		"email":         "user1@example.com",
+		"user":          map[string]interface{}{"email": "user1@example.com", "country": "SE"},
+		"ip":            "127.0.0.1",
+	}
+
+	redacted := Redaction{AllowKeys: []string{"targeting_key", "user"}, DenyKeys: []string{"email"}}.
+		Apply(evaluationContext)
+
+	assert.Equal(t, map[string]interface{}{
+		"targeting_key": "user1",
+		"email":         "[REDACTED]",
+		"user":          map[string]interface{}{"email": "[REDACTED]", "country": "SE"},
+		"ip":            "[REDACTED]",
+	}, redacted)
+	assert.Equal(t, "user1@example.com", evaluationContext["email"])
+	assert.Equal(t, evaluationContext, Redaction{}.Apply(evaluationContext))
+}
+
+func TestRedactionAlwaysCopiesTheContext(t *testing.T) {
+	evaluationContext := map[string]interface{}{"user": map[string]interface{}{"country": "SE"}}
+
+	copied := Redaction{}.Apply(evaluationContext)
+	copied["targeting_key"] = "user1"
+	copied["user"].(map[string]interface{})["country"] = "DK"
+
+	assert.Equal(t, map[string]interface{}{"user": map[string]interface{}{"country": "SE"}}, evaluationContext)
+	assert.Nil(t, Redaction{}.Apply(nil))
+}
+
+func TestAPIKeyIsMaskedWhenLoggedOrPrinted(t *testing.T) {
+	var logs bytes.Buffer
+	config := APIConfig{APIKey: "my-client-secret-1234"}
+
+	NewConfidenceBuilder().SetAPIConfig(config).SetLogger(slog.New(slog.NewTextHandler(&logs, nil))).Build()
+
+	assert.Contains(t, logs.String(), "APIKey:****1234")
+	assert.NotContains(t, logs.String(), "my-client-secret")
+	assert.NotContains(t, fmt.Sprint(config), "my-client-secret")
+	assert.Equal(t, "****", maskSecret("short"))
+}
+
+func TestResolveTesterHintIsOptIn(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		config := APIConfig{APIKey: "apiKey", EnableResolveTesterHint: enabled,
+			Redaction: Redaction{DenyKeys: []string{"email"}}}
+		confidence := NewConfidenceBuilder().SetAPIConfig(config).SetLogger(logger).
+			SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).Build()
+		confidence.PutContext("targeting_key", "user1")
+		confidence.PutContext("email", "user1@example.com")
+
+		confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+
+		payload := regexp.MustCompile(`Resolve tester" flag=test-flag payload="?([A-Za-z0-9+/=]*)`).
+			FindStringSubmatch(logs.String())
+		if !enabled {
+			assert.Nil(t, payload)
+			continue
+		}
+		assert.Len(t, payload, 2)
+		decoded, err := base64.StdEncoding.DecodeString(payload[1])
+		assert.NoError(t, err)
+		assert.Contains(t, string(decoded), `"email":"[REDACTED]"`)
+		assert.Contains(t, string(decoded), `"targeting_key":"user1"`)
+	}
+}
+
+func TestEvaluationListenersReceiveRedactedContexts(t *testing.T) {
+	var evaluations []Evaluation
+	config := APIConfig{APIKey: "apiKey", Redaction: Redaction{DenyKeys: []string{"email"}}}
+	confidence := NewConfidenceBuilder().SetAPIConfig(config).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).
+		AddEvaluationListener(EvaluationListenerFunc(func(evaluation Evaluation) {
+			evaluations = append(evaluations, evaluation)
+		})).Build()
+	confidence.PutContext("targeting_key", "user1")
+	confidence.PutContext("email", "user1@example.com")
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+
+	assert.Equal(t, "[REDACTED]", evaluations[0].Context["email"])
+	redacted := map[string]interface{}{"targeting_key": "user1", "email": "[REDACTED]"}
+	assert.Equal(t, contextHash(processHashKey, redacted), evaluations[0].ContextHash)
+
+	confidence.PutContext("email", "user2@example.com")
+	confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+
+	assert.Equal(t, evaluations[0].ContextHash, evaluations[1].ContextHash)
+}
@@ -27,6 +27,29 @@ type ResolutionDetail struct {
 // of type boolean, string, int64 or float64. This structure is populated by a provider for use by an Application
 // Author (via the Evaluation API) or an Application Integrator (via hooks).
 type FlagMetadata map[string]interface{}
+
+// Keys of the FlagMetadata populated for every evaluation.
+const (
+	// FlagMetadataFlag - the full name of the resolved flag, e.g. "flags/my-flag".
+	FlagMetadataFlag = "flag"
+	// FlagMetadataResolveToken - the token of the resolve that produced the value.
+	FlagMetadataResolveToken = "resolveToken"
+	// FlagMetadataSchemaType - the schema type of the requested property: bool, string, int, double, struct or list.
+	FlagMetadataSchemaType = "schemaType"
+	// FlagMetadataResolveLatencyMs - the time spent resolving the flag, in milliseconds.
+	FlagMetadataResolveLatencyMs = "resolveLatencyMs"
+	// FlagMetadataSource - where the value came from, see ResolveSource.
+	FlagMetadataSource = "source"
+	// FlagMetadataResolveReason - the reason reported by the resolver, e.g. "RESOLVE_REASON_FLAG_ARCHIVED".
+	FlagMetadataResolveReason = "resolveReason"
+	// FlagMetadataArchived - true for a flag that resolved to the DISABLED reason because it is archived.
+	FlagMetadataArchived = "archived"
+	// FlagMetadataOverride - where the last override applied to the value was set: code, env or file, see SetOverride.
+	FlagMetadataOverride = "override"
+	// FlagMetadataDomain - the OpenFeature domain of the provider the flag was evaluated through, see SdkInfo.
+	FlagMetadataDomain = "domain"
+)
+
 type Reason string
 
 type ErrorCode string
@@ -74,6 +97,18 @@ type IntResolutionDetail struct {
 	ResolutionDetail
 }
 
+// StringListResolutionDetail provides a resolution detail with []string type
+type StringListResolutionDetail = TypedResolutionDetail[[]string]
+
+// BoolListResolutionDetail provides a resolution detail with []bool type
+type BoolListResolutionDetail = TypedResolutionDetail[[]bool]
+
+// IntListResolutionDetail provides a resolution detail with []int64 type
+type IntListResolutionDetail = TypedResolutionDetail[[]int64]
+
+// FloatListResolutionDetail provides a resolution detail with []float64 type
+type FloatListResolutionDetail = TypedResolutionDetail[[]float64]
+
 // InterfaceResolutionDetail provides a resolution detail with interface{} type
 type InterfaceResolutionDetail struct {
 	Value interface{}
@@ -0,0 +1,95 @@
+package confidence
+
+import "sync"
+
+// StatusEventType identifies a change reported to the status listeners of a Confidence.
+type StatusEventType string
+
+const (
+	// StatusReady is reported when flags are first resolved, and when they are resolved again after an error or
+	// stale period.
+	StatusReady StatusEventType = "READY"
+	// StatusError is reported when the circuit to the resolver opens.
+	StatusError StatusEventType = "ERROR"
+	// StatusStale is reported when cached flags are served past their TTL because the resolver can't be reached.
+	StatusStale StatusEventType = "STALE"
+	// StatusConfigurationChanged is reported when refreshing a cached flag returns a different value.
+	StatusConfigurationChanged StatusEventType = "CONFIGURATION_CHANGED"
+)
+
+// StatusEvent describes a change in the state of a Confidence, or in the flags it serves.
+type StatusEvent struct {
+	Type    StatusEventType
+	Message string
+	// ChangedFlags holds the names of the changed flags for StatusConfigurationChanged.
+	ChangedFlags []string
+}
+
+// statusTracker keeps the current state of a Confidence and notifies listeners of changes. The zero value is not
+// ready, it becomes ready when flags are first resolved.
+type statusTracker struct {
+	mu    sync.Mutex
+	state StatusEventType
+	// listeners is replaced rather than modified, so that it can be notified without holding mu.
+	listeners []*statusListener
+}
+
+// statusListener wraps a listener function, so that it can be told apart from the others when removed.
+type statusListener struct {
+	notify func(StatusEvent)
+}
+
+// addListener registers listener and returns a function removing it.
+func (s *statusTracker) addListener(listener func(StatusEvent)) func() {
+	added := &statusListener{notify: listener}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(append([]*statusListener{}, s.listeners...), added)
+	return func() {
+		s.removeListener(added)
+	}
+}
+
+func (s *statusTracker) removeListener(removed *statusListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	listeners := make([]*statusListener, 0, len(s.listeners))
+	for _, listener := range s.listeners {
+		if listener != removed {
+			listeners = append(listeners, listener)
+		}
+	}
+	s.listeners = listeners
+}
+
+// transition moves to state and notifies the listeners, unless that already is the current state.
+func (s *statusTracker) transition(state StatusEventType, message string) {
+	s.mu.Lock()
+	if s.state == state {
+		s.mu.Unlock()
+		return
+	}
+	s.state = state
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	notify(listeners, StatusEvent{Type: state, Message: message})
+}
+
+func (s *statusTracker) configurationChanged(flags []string) {
+	s.mu.Lock()
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	notify(listeners, StatusEvent{
+		Type:         StatusConfigurationChanged,
+		Message:      "flag values changed",
+		ChangedFlags: flags,
+	})
+}
+
+func notify(listeners []*statusListener, event StatusEvent) {
+	for _, listener := range listeners {
+		listener.notify(event)
+	}
+}
@@ -0,0 +1,157 @@
+package confidence
+
+import (
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// telemetryBufferSize is the number of traces kept between two resolves, later traces are dropped.
+const telemetryBufferSize = 1000
+
+// libraryTrace is a telemetry trace together with the library it is attributed to.
+type libraryTrace struct {
+	library ProtoLibraryTraces_ProtoLibrary
+	version string
+	trace   *ProtoLibraryTraces_ProtoTrace
+}
+
+// telemetryCollector buffers the telemetry traces of a Confidence until they are sent to the resolver with the next
+// resolve request. It is shared by the Confidence, its children and its HttpResolveClient. A nil collector records
+// nothing.
+type telemetryCollector struct {
+	disabled bool
+	// version is the SDK_VERSION the traces of an unspecified library are attributed to.
+	version string
+	traces  chan libraryTrace
+	mu      sync.Mutex
+	// dropListeners are called for every trace dropped because the buffer is full.
+	dropListeners []func()
+}
+
+func newTelemetryCollector(config APIConfig) *telemetryCollector {
+	return &telemetryCollector{
+		disabled: config.DisableTelemetry,
+		version:  SDK_VERSION,
+		traces:   make(chan libraryTrace, telemetryBufferSize),
+	}
+}
+
+// recordRequest records the latency and outcome of a resolve made for info.
+func (t *telemetryCollector) recordRequest(info SdkInfo, duration time.Duration,
+	status ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_ProtoStatus) {
+	t.record(info, &ProtoLibraryTraces_ProtoTrace{
+		Id: ProtoLibraryTraces_PROTO_TRACE_ID_RESOLVE_LATENCY,
+		Trace: &ProtoLibraryTraces_ProtoTrace_RequestTrace{
+			RequestTrace: &ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace{
+				MillisecondDuration: uint64(duration.Milliseconds()),
+				Status:              status,
+			},
+		},
+	})
+}
+
+// recordCount records a single occurrence of id for info.
+func (t *telemetryCollector) recordCount(info SdkInfo, id ProtoLibraryTraces_ProtoTraceId) {
+	t.record(info, &ProtoLibraryTraces_ProtoTrace{
+		Id: id,
+		Trace: &ProtoLibraryTraces_ProtoTrace_CountTrace{
+			CountTrace: &ProtoLibraryTraces_ProtoTrace_ProtoCountTrace{},
+		},
+	})
+}
+
+func (t *telemetryCollector) record(info SdkInfo, trace *ProtoLibraryTraces_ProtoTrace) {
+	if t == nil || t.disabled {
+		return
+	}
+	library, version := t.library(info)
+	select {
+	case t.traces <- libraryTrace{library: library, version: version, trace: trace}:
+	default:
+		// Buffer is full, drop the trace
+		t.mu.Lock()
+		listeners := t.dropListeners
+		t.mu.Unlock()
+		for _, listener := range listeners {
+			listener()
+		}
+	}
+}
+
+// onDrop registers listener to be called for every trace dropped because the buffer is full.
+func (t *telemetryCollector) onDrop(listener func()) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropListeners = append(t.dropListeners, listener)
+}
+
+// pull removes and returns the traces recorded so far.
+func (t *telemetryCollector) pull() []libraryTrace {
+	traces := make([]libraryTrace, 0)
+	if t == nil {
+		return traces
+	}
+	for {
+		select {
+		case trace := <-t.traces:
+			traces = append(traces, trace)
+		default:
+			return traces
+		}
+	}
+}
+
+// header pulls the traces recorded so far and encodes them as the value of the telemetry header of a resolve made
+// for info. It returns false if telemetry is disabled.
+func (t *telemetryCollector) header(info SdkInfo) (string, bool) {
+	if t == nil || t.disabled {
+		return "", false
+	}
+	library, version := t.library(info)
+	monitoring := &ProtoMonitoring{
+		Platform:      ProtoPlatform_PROTO_PLATFORM_GO,
+		LibraryTraces: groupLibraryTraces(library, version, t.pull()),
+	}
+	monitoringBytes, err := proto.Marshal(monitoring)
+	if err != nil {
+		return "", false
+	}
+	return base64.StdEncoding.EncodeToString(monitoringBytes), true
+}
+
+// library returns the library and version the telemetry of a request made for info is attributed to.
+func (t *telemetryCollector) library(info SdkInfo) (ProtoLibraryTraces_ProtoLibrary, string) {
+	if info.Library == ProtoLibraryTraces_PROTO_LIBRARY_UNSPECIFIED {
+		return ProtoLibraryTraces_PROTO_LIBRARY_CONFIDENCE, t.version
+	}
+	return info.Library, info.Version
+}
+
+// groupLibraryTraces groups traces by library and version. The library of the current request always comes first,
+// even without traces.
+func groupLibraryTraces(library ProtoLibraryTraces_ProtoLibrary, version string,
+	traces []libraryTrace) []*ProtoLibraryTraces {
+	current := &ProtoLibraryTraces{Library: library, LibraryVersion: version}
+	groups := []*ProtoLibraryTraces{current}
+	for _, trace := range traces {
+		var group *ProtoLibraryTraces
+		for _, candidate := range groups {
+			if candidate.Library == trace.library && candidate.LibraryVersion == trace.version {
+				group = candidate
+				break
+			}
+		}
+		if group == nil {
+			group = &ProtoLibraryTraces{Library: trace.library, LibraryVersion: trace.version}
+			groups = append(groups, group)
+		}
+		group.Traces = append(group.Traces, trace.trace)
+	}
+	return groups
+}
@@ -0,0 +1,155 @@
+package confidence
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func traceIds(collector *telemetryCollector) []ProtoLibraryTraces_ProtoTraceId {
+	ids := make([]ProtoLibraryTraces_ProtoTraceId, 0)
+	for _, trace := range collector.pull() {
+		ids = append(ids, trace.trace.Id)
+	}
+	return ids
+}
+
+func TestTypeMismatchesAreRecorded(t *testing.T) {
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).Build()
+	confidence.telemetry = newTelemetryCollector(confidence.Config)
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.GetStringFlag(context.Background(), "test-flag.boolean-key", "default")
+	confidence.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+	var target struct {
+		Missing string `confidence:"missing-key"`
+	}
+	confidence.DecodeFlag(context.Background(), "test-flag", &target)
+
+	assert.Equal(t, []ProtoLibraryTraces_ProtoTraceId{
+		ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH,
+		ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH,
+	}, traceIds(confidence.telemetry))
+}
+
+func TestConversionMismatchesAreRecordedOnce(t *testing.T) {
+	confidence := newConfidence("apiKey", MockResolveClient{TestingT: t})
+	confidence.telemetry = newTelemetryCollector(confidence.Config)
+	resolved := ResolutionDetail{Reason: TargetingMatchReason}
+	mismatch := ResolutionDetail{Reason: ErrorReason, ErrorCode: TypeMismatchCode}
+
+	confidence.RecordConversion(resolved, mismatch)
+	confidence.RecordConversion(mismatch, mismatch)
+	confidence.RecordConversion(resolved, resolved)
+
+	assert.Equal(t, []ProtoLibraryTraces_ProtoTraceId{ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH},
+		traceIds(confidence.telemetry))
+}
+
+func TestWithContextIsRecorded(t *testing.T) {
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).Build()
+
+	child := confidence.WithContext(map[string]interface{}{"targeting_key": "user1"})
+	child.WithSdkInfo(SdkInfo{Id: "SDK_ID_GO_PROVIDER", Version: "1.2.3",
+		Library: ProtoLibraryTraces_PROTO_LIBRARY_OPEN_FEATURE}).WithContext(nil)
+
+	traces := confidence.telemetry.pull()
+	assert.Len(t, traces, 2)
+	for _, trace := range traces {
+		assert.Equal(t, ProtoLibraryTraces_PROTO_TRACE_ID_WITH_CONTEXT, trace.trace.Id)
+	}
+	assert.Equal(t, ProtoLibraryTraces_PROTO_LIBRARY_CONFIDENCE, traces[0].library)
+	assert.Equal(t, ProtoLibraryTraces_PROTO_LIBRARY_OPEN_FEATURE, traces[1].library)
+
+	child.WithEvaluationContext(map[string]interface{}{"country": "SE"})
+	assert.Empty(t, confidence.telemetry.pull())
+}
+
+func TestCachedAndStaleServesAreRecorded(t *testing.T) {
+	client := &sequenceResolveClient{
+		responses: []ResolveResponse{templateResponse(), {}},
+		errors:    []error{nil, errors.New("unavailable")},
+	}
+	clock := &fakeClock{current: time.Now()}
+	confidence, _ := newCachingConfidence(client, APIConfig{CacheTTL: time.Minute}, clock)
+	confidence.telemetry = newTelemetryCollector(confidence.Config)
+
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+	clock.current = clock.current.Add(2 * time.Minute)
+	confidence.GetStringFlag(context.Background(), "test-flag.string-key", "default")
+
+	traces := confidence.telemetry.pull()
+	assert.Len(t, traces, 2)
+	assert.Equal(t, ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_CACHED,
+		traces[0].trace.GetRequestTrace().Status)
+	assert.Equal(t, ProtoLibraryTraces_PROTO_TRACE_ID_STALE_FLAG, traces[1].trace.Id)
+}
+
+func TestConfidenceSharesTelemetryWithHttpResolveClient(t *testing.T) {
+	client := NewHttpResolveClient(APIConfig{APIKey: "apiKey"})
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).SetResolveClient(client).
+		SetLogger(slog.Default()).Build()
+
+	confidence.RecordTypeMismatch()
+
+	traces := client.PullTraces()
+	assert.Len(t, traces, 1)
+	assert.Equal(t, ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH, traces[0].Id)
+}
+
+func TestDisabledTelemetryRecordsNothing(t *testing.T) {
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey", DisableTelemetry: true}).Build()
+
+	confidence.RecordTypeMismatch()
+	confidence.WithContext(nil)
+
+	assert.Empty(t, confidence.telemetry.pull())
+	_, ok := confidence.telemetry.header(SdkInfo{})
+	assert.False(t, ok)
+}
+
+type droppedTracesInstrumentation struct {
+	recordingInstrumentation
+	dropped *int
+}
+
+func (d droppedTracesInstrumentation) TelemetryTraceDropped() {
+	*d.dropped++
+}
+
+func TestDroppedTracesAreReported(t *testing.T) {
+	var calls []string
+	dropped := 0
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		AddInstrumentation(droppedTracesInstrumentation{recordingInstrumentation{calls: &calls}, &dropped}).Build()
+
+	for i := 0; i < telemetryBufferSize+2; i++ {
+		confidence.RecordTypeMismatch()
+	}
+
+	assert.Equal(t, 2, dropped)
+	assert.Len(t, confidence.telemetry.pull(), telemetryBufferSize)
+}
+
+func TestCustomResolveClientDropsNoTraces(t *testing.T) {
+	var calls []string
+	dropped := 0
+	confidence := NewConfidenceBuilder().SetAPIConfig(APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).
+		AddInstrumentation(droppedTracesInstrumentation{recordingInstrumentation{calls: &calls}, &dropped}).Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	for i := 0; i < telemetryBufferSize+2; i++ {
+		confidence.GetStringFlag(context.Background(), "test-flag.boolean-key", "default")
+		confidence.WithContext(nil)
+	}
+
+	assert.Nil(t, confidence.telemetry)
+	assert.Zero(t, dropped)
+}
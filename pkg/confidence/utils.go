@@ -5,17 +5,34 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"reflect"
-	"strings"
-
-	"golang.org/x/exp/slog"
 )
 
 const ErrorReason Reason = "ERROR"
 const TargetingMatchReason Reason = "TARGETING_MATCH"
 const DefaultReason Reason = "DEFAULT"
+const DisabledReason Reason = "DISABLED"
+const CachedReason Reason = "CACHED"
+const StaticReason Reason = "STATIC"
+const UnknownReason Reason = "UNKNOWN"
+
+// hasValue reports whether a detail with this reason carries the resolved value rather than the default value.
+func (r Reason) hasValue() bool {
+	return r == TargetingMatchReason || r == CachedReason || r == StaticReason
+}
+
+// Reasons reported by the resolver service for a resolved flag.
+const (
+	resolveReasonNoSegmentMatch    = "RESOLVE_REASON_NO_SEGMENT_MATCH"
+	resolveReasonNoTreatmentMatch  = "RESOLVE_REASON_NO_TREATMENT_MATCH"
+	resolveReasonFlagArchived      = "RESOLVE_REASON_FLAG_ARCHIVED"
+	resolveReasonTargetingKeyError = "RESOLVE_REASON_TARGETING_KEY_ERROR"
+	resolveReasonError             = "RESOLVE_REASON_ERROR"
+)
 
+// logResolveTesterHint logs a payload to paste in the Resolve tester to check the evaluation of flagName.
 func logResolveTesterHint(logger *slog.Logger, flagName, apiKey string, context map[string]interface{}) {
 	object := map[string]interface{}{
 		"flag":      "flags/" + flagName,
@@ -24,78 +41,138 @@ func logResolveTesterHint(logger *slog.Logger, flagName, apiKey string, context
 	}
 	json, err := json.Marshal(object)
 	if err == nil {
-		base64 := base64.StdEncoding.EncodeToString(json)
-		logger.Debug("Check your flag evaluation for " + flagName + " by copy pasting the payload to the Resolve tester '" + base64 + "'")
+		logger.Debug("Check the flag evaluation by copy pasting the payload to the Resolve tester",
+			LogKeyFlag, flagName, LogKeyPayload, base64.StdEncoding.EncodeToString(json))
 	}
 }
 
-func splitFlagString(flag string) (string, string) {
-	splittedFlag := strings.SplitN(flag, ".", 2)
-	if len(splittedFlag) == 2 {
-		return splittedFlag[0], splittedFlag[1]
-	}
+func extractPropertyValue(path propertyPath, values map[string]interface{}) (interface{}, error) {
+	var current interface{} = values
+	for i, step := range path.steps {
+		if current == nil {
+			return false, fmt.Errorf("unable to find property %s, %s is null", path, path.describe(i))
+		}
 
-	return splittedFlag[0], ""
-}
+		if list, isList := current.([]interface{}); isList {
+			index, ok := step.listIndex()
+			if !ok {
+				return false, fmt.Errorf("unable to find property %s, %s is a list", path, path.describe(i))
+			}
+			if index >= len(list) {
+				return false, fmt.Errorf("unable to find property %s, index %d is out of range for %s with %d elements",
+					path, index, path.describe(i), len(list))
+			}
+			current = list[index]
+			continue
+		}
 
-func extractPropertyValue(path string, values map[string]interface{}) (interface{}, error) {
-	if path == "" {
-		return values, nil
+		childMap, ok := current.(map[string]interface{})
+		if !ok || step.isIndex {
+			return false, fmt.Errorf("unable to find property %s, %s is not a struct", path, path.describe(i))
+		}
+		current = childMap[step.key]
 	}
 
-	firstPartAndRest := strings.SplitN(path, ".", 2)
-	if len(firstPartAndRest) == 1 {
-		value := values[firstPartAndRest[0]]
-		return value, nil
+	return current, nil
+}
+
+func getTypeForPath(schema map[string]interface{}, path propertyPath) (reflect.Kind, error) {
+	if path.isEmpty() {
+		return reflect.Map, nil
 	}
 
-	childMap, ok := values[firstPartAndRest[0]].(map[string]interface{})
-	if ok {
-		return extractPropertyValue(firstPartAndRest[1], childMap)
+	propertySchema, err := getSchemaForPath(schema, path)
+	if err != nil {
+		return 0, err
 	}
 
-	return false, fmt.Errorf("unable to find property in path %s", path)
+	return schemaKind(propertySchema, path)
 }
 
-func getTypeForPath(schema map[string]interface{}, path string) (reflect.Kind, error) {
-	if path == "" {
-		return reflect.Map, nil
-	}
+// getSchemaForPath returns the schema of the property at path, e.g. {"boolSchema": {}}.
+// The empty path refers to the flag value itself, which is always a struct.
+func getSchemaForPath(schema map[string]interface{}, path propertyPath) (map[string]interface{}, error) {
+	current := map[string]interface{}{"structSchema": map[string]interface{}{"schema": schema}}
+	for i, step := range path.steps {
+		if _, isList := current["listSchema"]; isList {
+			if _, ok := step.listIndex(); !ok {
+				return nil, fmt.Errorf("unable to find property %q in schema, %s is a list", step.key, path.describe(i))
+			}
+			elementSchema, ok := listElement(current)
+			if !ok {
+				return nil, fmt.Errorf("schema for %s is not in the expected format", path.describe(i))
+			}
+			current = elementSchema
+			continue
+		}
 
-	firstPartAndRest := strings.SplitN(path, ".", 2)
-	if len(firstPartAndRest) == 1 {
-		value, ok := schema[firstPartAndRest[0]].(map[string]interface{})
+		if _, isStruct := current["structSchema"]; !isStruct || step.isIndex {
+			return nil, fmt.Errorf("unable to find property %s in schema, %s is not a struct", step, path.describe(i))
+		}
+		field, found := structFields(current)[step.key]
+		if !found {
+			return nil, fmt.Errorf("unable to find property %q in the schema of %s", step.key, path.describe(i))
+		}
+		next, ok := field.(map[string]interface{})
 		if !ok {
-			return 0, fmt.Errorf("schema was not in the expected format")
+			return nil, fmt.Errorf("schema for %s is not in the expected format", path.describe(i+1))
 		}
+		current = next
+	}
 
-		if _, isBool := value["boolSchema"]; isBool {
-			return reflect.Bool, nil
-		} else if _, isString := value["stringSchema"]; isString {
-			return reflect.String, nil
-		} else if _, isInt := value["intSchema"]; isInt {
-			return reflect.Int64, nil
-		} else if _, isFloat := value["doubleSchema"]; isFloat {
-			return reflect.Float64, nil
-		} else if _, isMap := value["structSchema"]; isMap {
-			return reflect.Map, nil
-		}
+	return current, nil
+}
 
-		return 0, fmt.Errorf("unable to find property type in schema %s", path)
+// schemaKind maps a property schema to the kind of value it describes.
+func schemaKind(propertySchema map[string]interface{}, path propertyPath) (reflect.Kind, error) {
+	if _, isBool := propertySchema["boolSchema"]; isBool {
+		return reflect.Bool, nil
+	} else if _, isString := propertySchema["stringSchema"]; isString {
+		return reflect.String, nil
+	} else if _, isInt := propertySchema["intSchema"]; isInt {
+		return reflect.Int64, nil
+	} else if _, isFloat := propertySchema["doubleSchema"]; isFloat {
+		return reflect.Float64, nil
+	} else if _, isMap := propertySchema["structSchema"]; isMap {
+		return reflect.Map, nil
+	} else if _, isList := propertySchema["listSchema"]; isList {
+		return reflect.Slice, nil
 	}
 
-	// If we are here, the property path contains multiple entries -> this must be a struct -> recurse down the tree.
-	childMap, ok := schema[firstPartAndRest[0]].(map[string]interface{})
-	if !ok {
-		return 0, fmt.Errorf("unexpected error when parsing resolve response schema")
-	}
+	return 0, fmt.Errorf("unable to find property type in schema of %s", path.description())
+}
 
-	if structMap, isStruct := childMap["structSchema"]; isStruct {
-		structSchema, _ := structMap.(map[string]interface{})["schema"].(map[string]interface{})
-		return getTypeForPath(structSchema, firstPartAndRest[1])
+// schemaTypeName names a schema kind the way the flag schema does, without the "Schema" suffix.
+func schemaTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "bool"
+	case reflect.String:
+		return "string"
+	case reflect.Int64:
+		return "int"
+	case reflect.Float64:
+		return "double"
+	case reflect.Map:
+		return "struct"
+	case reflect.Slice:
+		return "list"
 	}
+	return "unknown"
+}
+
+// structFields returns the schemas of the properties of a struct schema, keyed by property name.
+func structFields(propertySchema map[string]interface{}) map[string]interface{} {
+	structMap, _ := propertySchema["structSchema"].(map[string]interface{})
+	fields, _ := structMap["schema"].(map[string]interface{})
+	return fields
+}
 
-	return 0, fmt.Errorf("unable to find property in schema %s", path)
+// listElement returns the schema of the elements of a list schema.
+func listElement(propertySchema map[string]interface{}) (map[string]interface{}, bool) {
+	listMap, _ := propertySchema["listSchema"].(map[string]interface{})
+	elementSchema, ok := listMap["elementSchema"].(map[string]interface{})
+	return elementSchema, ok
 }
 
 func processResolveError(err error, defaultValue interface{}) InterfaceResolutionDetail {
@@ -114,6 +191,17 @@ func processResolveError(err error, defaultValue interface{}) InterfaceResolutio
 	}
 
 	switch {
+	case errors.Is(err, errCircuitOpen):
+		return InterfaceResolutionDetail{
+			Value: defaultValue,
+			ResolutionDetail: ResolutionDetail{
+				Variant:      "",
+				Reason:       DefaultReason,
+				ErrorCode:    ProviderNotReadyCode,
+				ErrorMessage: "error when resolving, circuit to the resolver is open",
+				FlagMetadata: nil,
+			},
+		}
 	case errors.Is(err, errFlagNotFound):
 		return InterfaceResolutionDetail{
 			Value: defaultValue,
@@ -138,8 +226,37 @@ func processResolveError(err error, defaultValue interface{}) InterfaceResolutio
 	}
 }
 
+// processResolverReason maps the resolver reasons that don't produce a value to the detail returned to the caller.
+// It returns false for matches, and for unspecified or unknown reasons, whose outcome depends on the value.
+func processResolverReason(reason string, defaultValue interface{}) (InterfaceResolutionDetail, bool) {
+	var detail ResolutionDetail
+	switch reason {
+	case resolveReasonNoSegmentMatch, resolveReasonNoTreatmentMatch:
+		detail = ResolutionDetail{Reason: DefaultReason}
+	case resolveReasonFlagArchived:
+		detail = ResolutionDetail{Reason: DisabledReason}
+	case resolveReasonTargetingKeyError:
+		err := NewInvalidContextResolutionError("the targeting key in the evaluation context is invalid")
+		detail = ResolutionDetail{Reason: ErrorReason, ErrorCode: err.code, ErrorMessage: err.message}
+	case resolveReasonError:
+		err := NewGeneralResolutionError("the resolver failed to resolve the flag")
+		detail = ResolutionDetail{Reason: ErrorReason, ErrorCode: err.code, ErrorMessage: err.message}
+	default:
+		return InterfaceResolutionDetail{}, false
+	}
+
+	return InterfaceResolutionDetail{
+		Value:            defaultValue,
+		ResolutionDetail: detail,
+	}, true
+}
+
 func processResolvedFlag(resolvedFlag resolvedFlag, defaultValue interface{},
-	expectedKind reflect.Kind, propertyPath string) InterfaceResolutionDetail {
+	expectedKind reflect.Kind, propertyPath propertyPath) InterfaceResolutionDetail {
+	if detail, handled := processResolverReason(resolvedFlag.Reason, defaultValue); handled {
+		return detail
+	}
+
 	if len(resolvedFlag.Value) == 0 {
 		return InterfaceResolutionDetail{
 			Value: defaultValue,
@@ -148,10 +265,12 @@ func processResolvedFlag(resolvedFlag resolvedFlag, defaultValue interface{},
 	}
 
 	actualKind, schemaErr := getTypeForPath(resolvedFlag.FlagSchema.Schema, propertyPath)
-	if schemaErr != nil || actualKind != expectedKind {
-		err := NewTypeMismatchResolutionError(
-			fmt.Sprintf("schema for property %s does not match the expected type",
-				propertyPath))
+	if schemaErr != nil || !kindMatches(expectedKind, actualKind) {
+		message := fmt.Sprintf("schema for property %s does not match the expected type", propertyPath)
+		if schemaErr != nil {
+			message = fmt.Sprintf("%s: %s", message, schemaErr)
+		}
+		err := NewTypeMismatchResolutionError(message)
 		return InterfaceResolutionDetail{
 			Value: defaultValue,
 			ResolutionDetail: ResolutionDetail{
@@ -163,7 +282,7 @@ func processResolvedFlag(resolvedFlag resolvedFlag, defaultValue interface{},
 			}}
 	}
 
-	updatedMap, err := replaceNumbers("", resolvedFlag.Value, resolvedFlag.FlagSchema.Schema)
+	updatedMap, err := replaceNumbers(resolvedFlag.Value, resolvedFlag.FlagSchema.Schema)
 	if err != nil {
 		return typeMismatchError(defaultValue)
 	}
@@ -180,56 +299,101 @@ func processResolvedFlag(resolvedFlag resolvedFlag, defaultValue interface{},
 			Variant: resolvedFlag.Variant}}
 }
 
-func replaceNumbers(basePath string, input map[string]interface{},
-	schema map[string]interface{}) (map[string]interface{}, error) {
+// kindMatches reports whether a property of kind actual can be returned when expected was requested.
+// reflect.Interface accepts any structured value, that is a struct or a list.
+func kindMatches(expected reflect.Kind, actual reflect.Kind) bool {
+	if expected == reflect.Interface {
+		return actual == reflect.Map || actual == reflect.Slice
+	}
+	return expected == actual
+}
+
+func replaceNumbers(input map[string]interface{}, schema map[string]interface{}) (map[string]interface{}, error) {
+	return replaceStructNumbers(input, schema, propertyPath{})
+}
+
+func replaceStructNumbers(input map[string]interface{}, fields map[string]interface{},
+	path propertyPath) (map[string]interface{}, error) {
 	updatedMap := make(map[string]interface{})
 	for key, value := range input {
-		kind, typeErr := getTypeForPath(schema, fmt.Sprintf("%s%s", basePath, key))
-		if typeErr != nil {
-			return updatedMap, fmt.Errorf("unable to get type for path %w", typeErr)
+		keyPath := path.withKey(key)
+		propertySchema, ok := fields[key].(map[string]interface{})
+		if !ok {
+			return updatedMap, fmt.Errorf("unable to get type for %s", keyPath.description())
 		}
 
-		switch kind {
-		case reflect.Float64:
-			if jsonNum, ok := value.(json.Number); ok {
-				floatValue, err := jsonNum.Float64()
-				if err != nil {
-					return updatedMap, fmt.Errorf("unable to convert to float")
-				}
+		updatedValue, err := replaceValueNumbers(value, propertySchema, keyPath)
+		if err != nil {
+			return updatedMap, err
+		}
 
-				value = floatValue
-			}
+		updatedMap[key] = updatedValue
+	}
 
-			updatedMap[key] = value
-		case reflect.Int64:
-			if jsonNum, ok := value.(json.Number); ok {
-				intValue, err := jsonNum.Int64()
-				if err != nil {
-					return updatedMap, fmt.Errorf("unable to convert to int")
-				}
+	return updatedMap, nil
+}
 
-				value = intValue
-			}
+// replaceValueNumbers converts the json.Number values found in value to int64 or float64, as declared by its schema.
+func replaceValueNumbers(value interface{}, propertySchema map[string]interface{}, path propertyPath) (interface{}, error) {
+	kind, typeErr := schemaKind(propertySchema, path)
+	if typeErr != nil {
+		return value, fmt.Errorf("unable to get type of %s: %w", path.description(), typeErr)
+	}
+	if value == nil {
+		return nil, nil
+	}
 
-			updatedMap[key] = value
-		case reflect.Map:
-			asMap, ok := value.(map[string]interface{})
-			if !ok {
-				return updatedMap, fmt.Errorf("unable to convert map")
+	switch kind {
+	case reflect.Float64:
+		if jsonNum, ok := value.(json.Number); ok {
+			floatValue, err := jsonNum.Float64()
+			if err != nil {
+				return value, fmt.Errorf("unable to convert %s to float: %w", path.description(), err)
 			}
 
-			childMap, err := replaceNumbers(fmt.Sprintf("%s%s.", basePath, key), asMap, schema)
+			return floatValue, nil
+		}
+	case reflect.Int64:
+		if jsonNum, ok := value.(json.Number); ok {
+			intValue, err := jsonNum.Int64()
 			if err != nil {
-				return updatedMap, fmt.Errorf("unable to convert map")
+				return value, fmt.Errorf("unable to convert %s to int: %w", path.description(), err)
 			}
 
-			updatedMap[key] = childMap
-		default:
-			updatedMap[key] = value
+			return intValue, nil
+		}
+	case reflect.Map:
+		asMap, ok := value.(map[string]interface{})
+		if !ok {
+			return value, fmt.Errorf("unable to convert %s to a struct, got %T", path.description(), value)
+		}
+
+		childMap, err := replaceStructNumbers(asMap, structFields(propertySchema), path)
+		if err != nil {
+			return value, fmt.Errorf("unable to convert struct %s: %w", path.description(), err)
+		}
+
+		return childMap, nil
+	case reflect.Slice:
+		asList, ok := value.([]interface{})
+		if !ok {
+			return value, fmt.Errorf("unable to convert %s to a list, got %T", path.description(), value)
 		}
+
+		elementSchema, _ := listElement(propertySchema)
+		updatedList := make([]interface{}, len(asList))
+		for i, element := range asList {
+			updatedElement, err := replaceValueNumbers(element, elementSchema, path.withIndex(i))
+			if err != nil {
+				return value, fmt.Errorf("unable to convert list %s: %w", path.description(), err)
+			}
+			updatedList[i] = updatedElement
+		}
+
+		return updatedList, nil
 	}
 
-	return updatedMap, nil
+	return value, nil
 }
 
 func typeMismatchError(defaultValue interface{}) InterfaceResolutionDetail {
@@ -248,7 +412,7 @@ func typeMismatchError(defaultValue interface{}) InterfaceResolutionDetail {
 
 func ToBoolResolutionDetail(res InterfaceResolutionDetail,
 	defaultValue bool) BoolResolutionDetail {
-	if res.ResolutionDetail.Reason == TargetingMatchReason && res.Value != nil {
+	if res.ResolutionDetail.Reason.hasValue() && res.Value != nil {
 		v, ok := res.Value.(bool)
 		if ok {
 			return BoolResolutionDetail{
@@ -266,7 +430,7 @@ func ToBoolResolutionDetail(res InterfaceResolutionDetail,
 				Reason:       ErrorReason,
 				ErrorCode:    err.code,
 				ErrorMessage: err.message,
-				FlagMetadata: nil}}
+				FlagMetadata: res.FlagMetadata}}
 	}
 
 	return BoolResolutionDetail{
@@ -277,7 +441,7 @@ func ToBoolResolutionDetail(res InterfaceResolutionDetail,
 
 func ToStringResolutionDetail(res InterfaceResolutionDetail,
 	defaultValue string) StringResolutionDetail {
-	if res.ResolutionDetail.Reason == TargetingMatchReason && res.Value != nil {
+	if res.ResolutionDetail.Reason.hasValue() && res.Value != nil {
 		v, ok := res.Value.(string)
 		if ok {
 			return StringResolutionDetail{
@@ -295,7 +459,7 @@ func ToStringResolutionDetail(res InterfaceResolutionDetail,
 				Reason:       ErrorReason,
 				ErrorCode:    err.code,
 				ErrorMessage: err.message,
-				FlagMetadata: nil,
+				FlagMetadata: res.FlagMetadata,
 			},
 		}
 	}
@@ -308,7 +472,7 @@ func ToStringResolutionDetail(res InterfaceResolutionDetail,
 
 func ToFloatResolutionDetail(res InterfaceResolutionDetail,
 	defaultValue float64) FloatResolutionDetail {
-	if res.ResolutionDetail.Reason == TargetingMatchReason && res.Value != nil {
+	if res.ResolutionDetail.Reason.hasValue() && res.Value != nil {
 		v, ok := res.Value.(float64)
 		if ok {
 			return FloatResolutionDetail{
@@ -326,7 +490,7 @@ func ToFloatResolutionDetail(res InterfaceResolutionDetail,
 				Reason:       ErrorReason,
 				ErrorCode:    err.code,
 				ErrorMessage: err.message,
-				FlagMetadata: nil,
+				FlagMetadata: res.FlagMetadata,
 			},
 		}
 	}
@@ -338,7 +502,7 @@ func ToFloatResolutionDetail(res InterfaceResolutionDetail,
 }
 
 func ToObjectResolutionDetail(res InterfaceResolutionDetail, defaultValue interface{}) InterfaceResolutionDetail {
-	if res.ResolutionDetail.Reason == TargetingMatchReason {
+	if res.ResolutionDetail.Reason.hasValue() {
 		v, ok := res.Value.(interface{})
 		if ok {
 			return InterfaceResolutionDetail{
@@ -356,7 +520,7 @@ func ToObjectResolutionDetail(res InterfaceResolutionDetail, defaultValue interf
 				Reason:       ErrorReason,
 				ErrorCode:    err.code,
 				ErrorMessage: err.message,
-				FlagMetadata: nil,
+				FlagMetadata: res.FlagMetadata,
 			},
 		}
 	}
@@ -369,7 +533,7 @@ func ToObjectResolutionDetail(res InterfaceResolutionDetail, defaultValue interf
 
 func ToIntResolutionDetail(res InterfaceResolutionDetail,
 	defaultValue int64) IntResolutionDetail {
-	if res.ResolutionDetail.Reason == TargetingMatchReason && res.Value != nil {
+	if res.ResolutionDetail.Reason.hasValue() && res.Value != nil {
 		v, ok := res.Value.(int64)
 		if ok {
 			return IntResolutionDetail{
@@ -387,7 +551,7 @@ func ToIntResolutionDetail(res InterfaceResolutionDetail,
 				Reason:       ErrorReason,
 				ErrorCode:    err.code,
 				ErrorMessage: err.message,
-				FlagMetadata: nil,
+				FlagMetadata: res.FlagMetadata,
 			},
 		}
 	}
@@ -4,31 +4,97 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestSpitFlagString(t *testing.T) {
+func TestParseFlagPath(t *testing.T) {
 	t.Run("FlagWithValue", func(t *testing.T) {
-		val1, val2 := splitFlagString("name.value")
-		assert.Equal(t, "name", val1)
-		assert.Equal(t, "value", val2)
+		name, path, err := parseFlagPath("name.value")
+		assert.NoError(t, err)
+		assert.Equal(t, "name", name)
+		assert.Equal(t, "value", path.String())
 	})
 
 	t.Run("FlagWithoutSecondPart", func(t *testing.T) {
-		val1, val2 := splitFlagString("novalue")
-		assert.Equal(t, "novalue", val1)
-		assert.Equal(t, "", val2)
+		name, path, err := parseFlagPath("novalue")
+		assert.NoError(t, err)
+		assert.Equal(t, "novalue", name)
+		assert.True(t, path.isEmpty())
 	})
 
 	t.Run("FlagWithMultipleDots", func(t *testing.T) {
-		val1, val2 := splitFlagString("double.dot.value")
-		assert.Equal(t, "double", val1)
-		assert.Equal(t, "dot.value", val2)
+		name, path, err := parseFlagPath("double.dot.value")
+		assert.NoError(t, err)
+		assert.Equal(t, "double", name)
+		assert.Equal(t, []pathStep{{key: "dot"}, {key: "value"}}, path.steps)
+	})
+
+	t.Run("PropertiesAndIndices", func(t *testing.T) {
+		name, path, err := parseFlagPath("flag.items[2].matrix[0][1]")
+		assert.NoError(t, err)
+		assert.Equal(t, "flag", name)
+		assert.Equal(t, []pathStep{
+			{key: "items"},
+			{index: 2, isIndex: true},
+			{key: "matrix"},
+			{index: 0, isIndex: true},
+			{index: 1, isIndex: true},
+		}, path.steps)
+	})
+
+	t.Run("QuotedAndEscapedSegments", func(t *testing.T) {
+		name, path, err := parseFlagPath(`flag["a.b"]."c[d]".e\.f`)
+		assert.NoError(t, err)
+		assert.Equal(t, "flag", name)
+		assert.Equal(t, []pathStep{{key: "a.b"}, {key: "c[d]"}, {key: "e.f"}}, path.steps)
+		assert.Equal(t, `["a.b"]["c[d]"]["e.f"]`, path.String())
+	})
+
+	t.Run("QuotedSegmentWithEscapedQuote", func(t *testing.T) {
+		_, path, err := parseFlagPath(`flag["say \"hi\""]`)
+		assert.NoError(t, err)
+		assert.Equal(t, []pathStep{{key: `say "hi"`}}, path.steps)
+	})
+
+	t.Run("JSONPointer", func(t *testing.T) {
+		name, path, err := parseFlagPath("flag/items/2/a~1b~0c")
+		assert.NoError(t, err)
+		assert.Equal(t, "flag", name)
+		assert.Equal(t, []pathStep{
+			{key: "items"},
+			{key: "2", index: 2, indexable: true},
+			{key: "a/b~c"},
+		}, path.steps)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, flag := range []string{
+			".value",
+			"flag.",
+			"flag..value",
+			"flag.items[x]",
+			"flag.items[1",
+			`flag["unterminated`,
+			`flag.value\`,
+			"flag//value",
+			"flag/a~2",
+		} {
+			_, _, err := parseFlagPath(flag)
+			assert.Error(t, err, flag)
+		}
 	})
 }
 
+func mustParsePath(t *testing.T, path string) propertyPath {
+	parsed, err := parsePropertyPath(path)
+	assert.NoError(t, err)
+	return parsed
+}
+
 func TestExtractPropertyValue(t *testing.T) {
 	t.Run("PathFromMap", func(t *testing.T) {
 		values := map[string]interface{}{
@@ -38,7 +104,7 @@ func TestExtractPropertyValue(t *testing.T) {
 			"key": "no-value",
 		}
 
-		got, err := extractPropertyValue("child.key", values)
+		got, err := extractPropertyValue(mustParsePath(t, "child.key"), values)
 		assert.NoError(t, err)
 		assert.Equal(t, "value", got)
 	})
@@ -48,7 +114,7 @@ func TestExtractPropertyValue(t *testing.T) {
 			"key": "direct-value",
 		}
 
-		got, err := extractPropertyValue("key", values)
+		got, err := extractPropertyValue(mustParsePath(t, "key"), values)
 		assert.NoError(t, err)
 		assert.Equal(t, "direct-value", got)
 	})
@@ -60,10 +126,34 @@ func TestExtractPropertyValue(t *testing.T) {
 			},
 		}
 
-		got, err := extractPropertyValue("invalid.path", values)
+		got, err := extractPropertyValue(mustParsePath(t, "invalid.path"), values)
 		assert.Error(t, err)
 		assert.Equal(t, false, got)
 	})
+
+	t.Run("PropertyWithDots", func(t *testing.T) {
+		values := map[string]interface{}{
+			"with.dots": map[string]interface{}{
+				"key": "value",
+			},
+		}
+
+		got, err := extractPropertyValue(mustParsePath(t, `"with.dots".key`), values)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", got)
+	})
+
+	t.Run("ErrorNamesFailingSegment", func(t *testing.T) {
+		values := map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "first"},
+			},
+		}
+
+		_, err := extractPropertyValue(mustParsePath(t, "items[3].name"), values)
+		assert.EqualError(t, err,
+			"unable to find property items[3].name, index 3 is out of range for property items with 1 elements")
+	})
 }
 
 func TestGetTypeForPath(t *testing.T) {
@@ -72,7 +162,7 @@ func TestGetTypeForPath(t *testing.T) {
 			"key": "value",
 		}
 
-		got, err := getTypeForPath(schema, "")
+		got, err := getTypeForPath(schema, mustParsePath(t, ""))
 		assert.NoError(t, err)
 		assert.Equal(t, reflect.Map, got)
 	})
@@ -84,7 +174,7 @@ func TestGetTypeForPath(t *testing.T) {
 			},
 		}
 
-		got, err := getTypeForPath(schema, "key")
+		got, err := getTypeForPath(schema, mustParsePath(t, "key"))
 		assert.NoError(t, err)
 		assert.Equal(t, reflect.Bool, got)
 	})
@@ -96,7 +186,7 @@ func TestGetTypeForPath(t *testing.T) {
 			},
 		}
 
-		got, err := getTypeForPath(schema, "key")
+		got, err := getTypeForPath(schema, mustParsePath(t, "key"))
 		assert.NoError(t, err)
 		assert.Equal(t, reflect.String, got)
 	})
@@ -108,7 +198,7 @@ func TestGetTypeForPath(t *testing.T) {
 			},
 		}
 
-		got, err := getTypeForPath(schema, "key")
+		got, err := getTypeForPath(schema, mustParsePath(t, "key"))
 		assert.NoError(t, err)
 		assert.Equal(t, reflect.Int64, got)
 	})
@@ -120,7 +210,7 @@ func TestGetTypeForPath(t *testing.T) {
 			},
 		}
 
-		got, err := getTypeForPath(schema, "key")
+		got, err := getTypeForPath(schema, mustParsePath(t, "key"))
 		assert.NoError(t, err)
 		assert.Equal(t, reflect.Float64, got)
 	})
@@ -138,11 +228,31 @@ func TestGetTypeForPath(t *testing.T) {
 			},
 		}
 
-		got, err := getTypeForPath(schema, "key.nested")
+		got, err := getTypeForPath(schema, mustParsePath(t, "key.nested"))
 		assert.NoError(t, err)
 		assert.Equal(t, reflect.Map, got)
 	})
 
+	t.Run("ListSchema", func(t *testing.T) {
+		schema := map[string]interface{}{
+			"key": map[string]interface{}{
+				"listSchema": map[string]interface{}{
+					"elementSchema": map[string]interface{}{
+						"stringSchema": map[string]interface{}{},
+					},
+				},
+			},
+		}
+
+		got, err := getTypeForPath(schema, mustParsePath(t, "key"))
+		assert.NoError(t, err)
+		assert.Equal(t, reflect.Slice, got)
+
+		got, err = getTypeForPath(schema, mustParsePath(t, "key[3]"))
+		assert.NoError(t, err)
+		assert.Equal(t, reflect.String, got)
+	})
+
 	t.Run("PropertyNotFound", func(t *testing.T) {
 		schema := map[string]interface{}{
 			"valid": map[string]interface{}{
@@ -150,9 +260,22 @@ func TestGetTypeForPath(t *testing.T) {
 			},
 		}
 
-		_, err := getTypeForPath(schema, "invalid")
+		_, err := getTypeForPath(schema, mustParsePath(t, "invalid"))
 		assert.Error(t, err)
 	})
+
+	t.Run("NestedPropertyNotFound", func(t *testing.T) {
+		schema := map[string]interface{}{
+			"struct": map[string]interface{}{
+				"structSchema": map[string]interface{}{
+					"schema": map[string]interface{}{},
+				},
+			},
+		}
+
+		_, err := getTypeForPath(schema, mustParsePath(t, "struct.missing.leaf"))
+		assert.EqualError(t, err, `unable to find property "missing" in the schema of property struct`)
+	})
 }
 
 func TestProcessResolveError(t *testing.T) {
@@ -193,7 +316,7 @@ func TestProcessResolvedFlag(t *testing.T) {
 			},
 		}
 
-		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, ""))
+		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, mustParsePath(t, "")))
 	})
 
 	t.Run("TypeMismatchError", func(t *testing.T) {
@@ -206,15 +329,16 @@ func TestProcessResolvedFlag(t *testing.T) {
 		expected := InterfaceResolutionDetail{
 			Value: defaultValue,
 			ResolutionDetail: ResolutionDetail{
-				Variant:      "",
-				Reason:       ErrorReason,
-				ErrorCode:    TypeMismatchCode,
-				ErrorMessage: "schema for property key does not match the expected type",
+				Variant:   "",
+				Reason:    ErrorReason,
+				ErrorCode: TypeMismatchCode,
+				ErrorMessage: "schema for property key does not match the expected type: " +
+					"schema for property key is not in the expected format",
 				FlagMetadata: nil,
 			},
 		}
 
-		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, "key"))
+		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, mustParsePath(t, "key")))
 	})
 
 	t.Run("ExtractValueError", func(t *testing.T) {
@@ -226,14 +350,15 @@ func TestProcessResolvedFlag(t *testing.T) {
 
 		expected := typeMismatchError(defaultValue)
 		expected.ResolutionDetail = ResolutionDetail{
-			Variant:      "",
-			Reason:       ErrorReason,
-			ErrorCode:    TypeMismatchCode,
-			ErrorMessage: "schema for property key.missing does not match the expected type",
+			Variant:   "",
+			Reason:    ErrorReason,
+			ErrorCode: TypeMismatchCode,
+			ErrorMessage: "schema for property key.missing does not match the expected type: " +
+				"schema for property key is not in the expected format",
 			FlagMetadata: nil,
 		}
 
-		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, "key.missing"))
+		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, mustParsePath(t, "key.missing")))
 	})
 
 	t.Run("Success", func(t *testing.T) {
@@ -249,10 +374,47 @@ func TestProcessResolvedFlag(t *testing.T) {
 				Reason: TargetingMatchReason,
 			},
 		}
-		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, "key"))
+		assert.Equal(t, expected, processResolvedFlag(rf, defaultValue, reflect.String, mustParsePath(t, "key")))
 	})
 }
 
+func TestProcessResolvedFlagResolverReasons(t *testing.T) {
+	tests := []struct {
+		reason       string
+		expectReason Reason
+		expectCode   ErrorCode
+	}{
+		{"RESOLVE_REASON_MATCH", TargetingMatchReason, ""},
+		{"RESOLVE_REASON_NO_SEGMENT_MATCH", DefaultReason, ""},
+		{"RESOLVE_REASON_NO_TREATMENT_MATCH", DefaultReason, ""},
+		{"RESOLVE_REASON_FLAG_ARCHIVED", DisabledReason, ""},
+		{"RESOLVE_REASON_TARGETING_KEY_ERROR", ErrorReason, InvalidContextCode},
+		{"RESOLVE_REASON_ERROR", ErrorReason, GeneralCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			rf := resolvedFlag{
+				Reason:     tt.reason,
+				Value:      map[string]interface{}{"key": "value"},
+				FlagSchema: flagSchema{Schema: map[string]interface{}{"key": map[string]interface{}{"stringSchema": "value"}}},
+			}
+			if tt.expectReason != TargetingMatchReason {
+				rf.Value = map[string]interface{}{}
+			}
+
+			got := processResolvedFlag(rf, "default", reflect.String, mustParsePath(t, "key"))
+			assert.Equal(t, tt.expectReason, got.Reason)
+			assert.Equal(t, tt.expectCode, got.ErrorCode)
+			if tt.expectReason == TargetingMatchReason {
+				assert.Equal(t, "value", got.Value)
+			} else {
+				assert.Equal(t, "default", got.Value)
+			}
+		})
+	}
+}
+
 func TestReplaceNumbers(t *testing.T) {
 	t.Run("SuccessfulFlowFloat64", func(t *testing.T) {
 		schema := map[string]interface{}{
@@ -262,7 +424,7 @@ func TestReplaceNumbers(t *testing.T) {
 		}
 		input := map[string]interface{}{"key": json.Number("123.45")}
 		expected := map[string]interface{}{"key": float64(123.45)}
-		updatedMap, err := replaceNumbers("", input, schema)
+		updatedMap, err := replaceNumbers(input, schema)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expected, updatedMap)
@@ -276,7 +438,7 @@ func TestReplaceNumbers(t *testing.T) {
 		}
 		input := map[string]interface{}{"key": json.Number("123")}
 		expected := map[string]interface{}{"key": int64(123)}
-		updatedMap, err := replaceNumbers("", input, schema)
+		updatedMap, err := replaceNumbers(input, schema)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expected, updatedMap)
@@ -304,7 +466,7 @@ func TestReplaceNumbers(t *testing.T) {
 				"subKey": float64(123.45),
 			},
 		}
-		updatedMap, err := replaceNumbers("", input, schema)
+		updatedMap, err := replaceNumbers(input, schema)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expected, updatedMap)
@@ -345,12 +507,50 @@ func TestReplaceNumbers(t *testing.T) {
 			},
 		}
 
-		updatedMap, err := replaceNumbers("", input, schema)
+		updatedMap, err := replaceNumbers(input, schema)
 		assert.NoError(t, err)
 		assert.Equal(t, expected, updatedMap)
 	})
 }
 
+func TestReplaceNumbersInList(t *testing.T) {
+	schema := map[string]interface{}{
+		"key": map[string]interface{}{
+			"listSchema": map[string]interface{}{
+				"elementSchema": map[string]interface{}{
+					"intSchema": map[string]interface{}{},
+				},
+			},
+		},
+	}
+	input := map[string]interface{}{"key": []interface{}{json.Number("1"), nil, json.Number("3")}}
+	expected := map[string]interface{}{"key": []interface{}{int64(1), nil, int64(3)}}
+	updatedMap, err := replaceNumbers(input, schema)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, updatedMap)
+}
+
+func TestReplaceNumbersErrorsNameTheProperty(t *testing.T) {
+	schema := map[string]interface{}{
+		"limits": map[string]interface{}{"structSchema": map[string]interface{}{"schema": map[string]interface{}{
+			"daily": map[string]interface{}{"listSchema": map[string]interface{}{
+				"elementSchema": map[string]interface{}{"intSchema": map[string]interface{}{}},
+			}},
+		}}},
+	}
+	input := map[string]interface{}{"limits": map[string]interface{}{
+		"daily": []interface{}{json.Number("1"), json.Number("2.5")},
+	}}
+
+	_, err := replaceNumbers(input, schema)
+
+	var numErr *strconv.NumError
+	assert.ErrorAs(t, err, &numErr)
+	assert.Contains(t, err.Error(), "unable to convert property limits.daily[1] to int")
+	assert.True(t, strings.HasPrefix(err.Error(), "unable to convert struct property limits: "))
+}
+
 func TestTypeMismatchError(t *testing.T) {
 	t.Run("WithStringValue", func(t *testing.T) {
 		defaultValue := "my default value"
@@ -0,0 +1,361 @@
+// Package confidencetest provides an in-memory fake of the Confidence resolver, to test code using Confidence
+// without the network and without writing resolve responses by hand.
+//
+//	resolver := confidencetest.NewResolver()
+//	resolver.SetFlag("checkout", map[string]interface{}{"enabled": true, "max-items": 10}).
+//		Override(map[string]interface{}{"country": "SE"}, "sweden", map[string]interface{}{"enabled": false})
+//	confidence := resolver.Builder().Build()
+//	confidence.PutContext("targeting_key", "user1")
+//
+//	confidence.GetBoolFlag(ctx, "checkout.enabled", false) // true
+//	resolver.AssertResolved(t, "checkout")
+//
+// The schema of a flag is inferred from the Go types of its values, so that integers, doubles, structs and lists are
+// decoded the same way as the values of a flag created in the Confidence console.
+package confidencetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+)
+
+// ClientSecret is the client secret of the Confidence instances created by Resolver.Builder.
+const ClientSecret = "confidencetest-client-secret"
+
+// ResolveToken is the resolve token of the responses of a Resolver.
+const ResolveToken = "confidencetest-resolve-token"
+
+// Resolver is a fake c.ResolveClient resolving the flags declared with SetFlag. It records the resolve requests and
+// the tracked events, for the assertions of the test. A Resolver is safe for concurrent use.
+type Resolver struct {
+	mu       sync.Mutex
+	flags    map[string]*Flag
+	err      error
+	requests []c.ResolveRequest
+	applied  []string
+	events   []c.Event
+}
+
+// NewResolver returns a Resolver without any flags, every flag resolves to FLAG_NOT_FOUND until it is declared.
+func NewResolver() *Resolver {
+	return &Resolver{flags: map[string]*Flag{}}
+}
+
+// Builder returns a c.ConfidenceBuilder resolving flags through r and tracking events to r. Flags aren't cached, so
+// that every evaluation sends a resolve request to r.
+func (r *Resolver) Builder() c.ConfidenceBuilder {
+	return c.NewConfidenceBuilder().
+		SetAPIConfig(c.APIConfig{APIKey: ClientSecret}).
+		SetResolveClient(r).
+		SetEventUploader(c.EventUploaderFunc(r.upload))
+}
+
+// SetFlag declares the flag name, replacing an earlier declaration of it, and returns it for further configuration.
+// The flag resolves to the variant "default" with value for every context that doesn't match an override.
+//
+// The values of value may be booleans, strings, integers, floating point numbers, maps with string keys and slices
+// of these. SetFlag panics if the schema of value can't be inferred, e.g. for a nil value or an empty []interface{}.
+func (r *Resolver) SetFlag(name string, value map[string]interface{}) *Flag {
+	flag := &Flag{resolver: r, name: name, variant: "default", value: normalize(value)}
+	flag.schema = mustInferSchema(name, value)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flags[name] = flag
+	return flag
+}
+
+// RemoveFlag removes the declaration of the flag name, it resolves to FLAG_NOT_FOUND afterwards.
+func (r *Resolver) RemoveFlag(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.flags, name)
+}
+
+// SetError makes every resolve request fail with err, until it is called with nil.
+func (r *Resolver) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Reset forgets the recorded resolve requests and tracked events. The declared flags are kept.
+func (r *Resolver) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = nil
+	r.applied = nil
+	r.events = nil
+}
+
+// SendResolveRequest records request and resolves the declared flags it asks for.
+func (r *Resolver) SendResolveRequest(_ context.Context, request c.ResolveRequest) (c.ResolveResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request.EvaluationContext = normalize(request.EvaluationContext)
+	request.Flags = append([]string{}, request.Flags...)
+	r.requests = append(r.requests, request)
+	if request.Apply {
+		r.apply(request.Flags)
+	}
+	if r.err != nil {
+		return c.ResolveResponse{}, r.err
+	}
+
+	requested := request.Flags
+	if len(requested) == 0 {
+		// Like the resolver service, a request without flags resolves all of them.
+		for name := range r.flags {
+			requested = append(requested, "flags/"+name)
+		}
+		sort.Strings(requested)
+	}
+	resolvedFlags := []interface{}{}
+	for _, requested := range requested {
+		flag, ok := r.flags[strings.TrimPrefix(requested, "flags/")]
+		if ok {
+			resolvedFlags = append(resolvedFlags, flag.resolve(request.EvaluationContext))
+		}
+	}
+	var response c.ResolveResponse
+	payload, err := json.Marshal(map[string]interface{}{"resolvedFlags": resolvedFlags, "resolveToken": ResolveToken})
+	if err != nil {
+		return response, err
+	}
+	// Numbers are decoded the way the resolve client decodes the responses of the resolver service.
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	err = decoder.Decode(&response)
+	return response, err
+}
+
+// apply records flags, the names of applied flags in the form "flags/<flag>", as applied. r.mu must be held.
+func (r *Resolver) apply(flags []string) {
+	for _, flag := range flags {
+		r.applied = append(r.applied, strings.TrimPrefix(flag, "flags/"))
+	}
+}
+
+func (r *Resolver) upload(_ context.Context, request c.EventBatchRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range request.Events {
+		event.Payload = normalize(event.Payload)
+		r.events = append(r.events, event)
+	}
+	return nil
+}
+
+// Requests returns the resolve requests received, in order.
+func (r *Resolver) Requests() []c.ResolveRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]c.ResolveRequest{}, r.requests...)
+}
+
+// Resolved returns the names of the flags resolve requests were received for, in order.
+func (r *Resolver) Resolved() []string {
+	names := []string{}
+	for _, request := range r.Requests() {
+		for _, flag := range request.Flags {
+			names = append(names, strings.TrimPrefix(flag, "flags/"))
+		}
+	}
+	return names
+}
+
+// Applied returns the names of the flags resolved with apply, or applied through the apply endpoint of a Server, in
+// order. Confidence applies the flags it resolves.
+func (r *Resolver) Applied() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.applied...)
+}
+
+// Events returns the tracked events received, in order. Events are uploaded asynchronously, wait for the
+// c.Confidence to be flushed before reading them.
+func (r *Resolver) Events() []c.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]c.Event{}, r.events...)
+}
+
+// Tracked returns the names of the tracked events received, in order.
+func (r *Resolver) Tracked() []string {
+	names := []string{}
+	for _, event := range r.Events() {
+		names = append(names, strings.TrimPrefix(event.EventDefinition, "eventDefinitions/"))
+	}
+	return names
+}
+
+// AssertResolved fails the test if flag wasn't resolved.
+func (r *Resolver) AssertResolved(t testing.TB, flag string) bool {
+	t.Helper()
+	if !contains(r.Resolved(), flag) {
+		t.Errorf("confidencetest: flag %q was not resolved, resolved flags: %v", flag, r.Resolved())
+		return false
+	}
+	return true
+}
+
+// AssertResolvedWithContext fails the test if flag wasn't resolved for an evaluation context holding the attributes
+// of context. Attributes not in context are ignored.
+func (r *Resolver) AssertResolvedWithContext(t testing.TB, flag string, context map[string]interface{}) bool {
+	t.Helper()
+	context = normalize(context)
+	contexts := []map[string]interface{}{}
+	for _, request := range r.Requests() {
+		if contains(request.Flags, "flags/"+flag) {
+			if matches(request.EvaluationContext, context) {
+				return true
+			}
+			contexts = append(contexts, request.EvaluationContext)
+		}
+	}
+	t.Errorf("confidencetest: flag %q was not resolved for context %v, resolved for: %v", flag, context, contexts)
+	return false
+}
+
+// AssertNotResolved fails the test if flag was resolved.
+func (r *Resolver) AssertNotResolved(t testing.TB, flag string) bool {
+	t.Helper()
+	if contains(r.Resolved(), flag) {
+		t.Errorf("confidencetest: flag %q was resolved", flag)
+		return false
+	}
+	return true
+}
+
+// AssertApplied fails the test if flag wasn't resolved with apply.
+func (r *Resolver) AssertApplied(t testing.TB, flag string) bool {
+	t.Helper()
+	if !contains(r.Applied(), flag) {
+		t.Errorf("confidencetest: flag %q was not applied, applied flags: %v", flag, r.Applied())
+		return false
+	}
+	return true
+}
+
+// AssertTracked fails the test if no event named event was tracked.
+func (r *Resolver) AssertTracked(t testing.TB, event string) bool {
+	t.Helper()
+	if !contains(r.Tracked(), event) {
+		t.Errorf("confidencetest: event %q was not tracked, tracked events: %v", event, r.Tracked())
+		return false
+	}
+	return true
+}
+
+// Flag is a flag declared with Resolver.SetFlag.
+type Flag struct {
+	resolver  *Resolver
+	name      string
+	variant   string
+	value     map[string]interface{}
+	schema    map[string]interface{}
+	overrides []override
+}
+
+// override resolves a flag to variant for the contexts holding the attributes of context.
+type override struct {
+	context map[string]interface{}
+	variant string
+	value   map[string]interface{}
+}
+
+// Variant sets the variant of the flag for the contexts that don't match an override, e.g. "treatment" for the
+// variant "flags/<flag>/variants/treatment".
+func (f *Flag) Variant(variant string) *Flag {
+	f.resolver.mu.Lock()
+	defer f.resolver.mu.Unlock()
+	f.variant = variant
+	return f
+}
+
+// Override resolves the flag to variant and value for the evaluation contexts holding the attributes of context.
+// Overrides are matched in the order they are added. An empty variant resolves matching contexts to no variant, so
+// that the default value is returned, like a flag without a matching rule.
+//
+// The schema of the flag covers the values of its overrides too. Override panics if the schema of value can't be
+// inferred or conflicts with the other values of the flag.
+func (f *Flag) Override(context map[string]interface{}, variant string, value map[string]interface{}) *Flag {
+	schema := mustInferSchema(f.name, value)
+	f.resolver.mu.Lock()
+	defer f.resolver.mu.Unlock()
+	merged, err := mergeFields(f.schema, schema)
+	if err != nil {
+		panic(fmt.Sprintf("confidencetest: flag %q: %v", f.name, err))
+	}
+	f.schema = merged
+	f.overrides = append(f.overrides, override{context: normalize(context), variant: variant, value: normalize(value)})
+	return f
+}
+
+// resolve returns the resolved flag for evaluationContext, in the format of the resolver service.
+func (f *Flag) resolve(evaluationContext map[string]interface{}) map[string]interface{} {
+	variant, value := f.variant, f.value
+	for _, override := range f.overrides {
+		if matches(evaluationContext, override.context) {
+			variant, value = override.variant, override.value
+			break
+		}
+	}
+	resolved := map[string]interface{}{
+		"flag":       "flags/" + f.name,
+		"flagSchema": map[string]interface{}{"schema": f.schema},
+	}
+	if variant == "" {
+		resolved["reason"] = "RESOLVE_REASON_NO_SEGMENT_MATCH"
+		return resolved
+	}
+	resolved["variant"] = fmt.Sprintf("flags/%s/variants/%s", f.name, variant)
+	resolved["reason"] = "RESOLVE_REASON_MATCH"
+	resolved["value"] = value
+	return resolved
+}
+
+// matches reports whether evaluationContext holds every attribute of attributes, both in their JSON form.
+func matches(evaluationContext, attributes map[string]interface{}) bool {
+	for key, value := range attributes {
+		actual, ok := evaluationContext[key]
+		if !ok || !reflect.DeepEqual(actual, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize returns value as it reads once encoded to JSON and decoded, so that values of different Go types
+// compare equal, e.g. int 1 and float64 1.
+func normalize(value map[string]interface{}) map[string]interface{} {
+	if value == nil {
+		return nil
+	}
+	payload, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("confidencetest: %v", err))
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(payload, &normalized); err != nil {
+		panic(fmt.Sprintf("confidencetest: %v", err))
+	}
+	return normalized
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package confidencetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records the failures of the assertions under test.
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func TestResolvesDeclaredFlagsWithInferredSchemas(t *testing.T) {
+	resolver := NewResolver()
+	resolver.SetFlag("checkout", map[string]interface{}{
+		"enabled":   true,
+		"max-items": 10,
+		"discount":  0.5,
+		"label":     "Buy",
+		"tags":      []string{"a", "b"},
+		"limits":    map[string]interface{}{"daily": int64(3)},
+	}).Variant("treatment")
+	confidence := resolver.Builder().Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	enabled := confidence.GetBoolFlag(context.Background(), "checkout.enabled", false)
+	assert.True(t, enabled.Value)
+	assert.Equal(t, c.TargetingMatchReason, enabled.Reason)
+	assert.Equal(t, "flags/checkout/variants/treatment", enabled.Variant)
+	assert.Equal(t, ResolveToken, enabled.FlagMetadata[c.FlagMetadataResolveToken])
+	assert.Equal(t, int64(10), confidence.GetIntValue(context.Background(), "checkout.max-items", 0))
+	assert.Equal(t, 0.5, confidence.GetDoubleValue(context.Background(), "checkout.discount", 0))
+	assert.Equal(t, "Buy", confidence.GetStringValue(context.Background(), "checkout.label", ""))
+	assert.Equal(t, []string{"a", "b"}, confidence.GetStringListValue(context.Background(), "checkout.tags", nil))
+	assert.Equal(t, int64(3), confidence.GetIntValue(context.Background(), "checkout.limits.daily", 0))
+	assert.Equal(t, c.TypeMismatchCode,
+		confidence.GetStringFlag(context.Background(), "checkout.max-items", "").ErrorCode)
+}
+
+func TestUndeclaredFlagsAreNotFound(t *testing.T) {
+	resolver := NewResolver()
+	confidence := resolver.Builder().Build()
+
+	detail := confidence.GetBoolFlag(context.Background(), "missing.enabled", false)
+
+	assert.Equal(t, c.FlagNotFoundCode, detail.ErrorCode)
+	resolver.AssertResolved(t, "missing")
+}
+
+func TestOverridesMatchContexts(t *testing.T) {
+	resolver := NewResolver()
+	resolver.SetFlag("checkout", map[string]interface{}{"enabled": true}).
+		Override(map[string]interface{}{"country": "SE", "age": 30}, "sweden",
+			map[string]interface{}{"enabled": false, "currency": "SEK"}).
+		Override(map[string]interface{}{"targeting_key": "user3"}, "", nil)
+	confidence := resolver.Builder().Build()
+
+	swede := confidence.WithContext(map[string]interface{}{"targeting_key": "user1", "country": "SE", "age": 30.0})
+	detail := swede.GetBoolFlag(context.Background(), "checkout.enabled", true)
+	assert.False(t, detail.Value)
+	assert.Equal(t, "flags/checkout/variants/sweden", detail.Variant)
+	assert.Equal(t, "SEK", swede.GetStringValue(context.Background(), "checkout.currency", ""))
+
+	other := confidence.WithContext(map[string]interface{}{"targeting_key": "user2", "country": "NO"})
+	assert.True(t, other.GetBoolValue(context.Background(), "checkout.enabled", false))
+	assert.Equal(t, "", other.GetStringValue(context.Background(), "checkout.currency", ""))
+
+	unmatched := confidence.WithContext(map[string]interface{}{"targeting_key": "user3"})
+	detail = unmatched.GetBoolFlag(context.Background(), "checkout.enabled", false)
+	assert.False(t, detail.Value)
+	assert.Equal(t, c.DefaultReason, detail.Reason)
+}
+
+func TestRecordsResolvedAppliedAndTracked(t *testing.T) {
+	resolver := NewResolver()
+	resolver.SetFlag("checkout", map[string]interface{}{"enabled": true})
+	confidence := resolver.Builder().Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.GetBoolFlag(context.Background(), "checkout.enabled", false)
+	confidence.Track(context.Background(), "purchase", map[string]interface{}{"amount": 10})
+	assert.NoError(t, confidence.Flush(context.Background()))
+
+	assert.True(t, resolver.AssertResolved(t, "checkout"))
+	assert.True(t, resolver.AssertResolvedWithContext(t, "checkout", map[string]interface{}{"targeting_key": "user1"}))
+	assert.True(t, resolver.AssertApplied(t, "checkout"))
+	assert.True(t, resolver.AssertNotResolved(t, "other"))
+	assert.True(t, resolver.AssertTracked(t, "purchase"))
+	assert.Equal(t, 10.0, resolver.Events()[0].Payload["amount"])
+	assert.Equal(t, ClientSecret, resolver.Requests()[0].ClientSecret)
+
+	recorder := &recordingT{TB: t}
+	assert.False(t, resolver.AssertResolved(recorder, "other"))
+	assert.False(t, resolver.AssertResolvedWithContext(recorder, "checkout",
+		map[string]interface{}{"targeting_key": "user2"}))
+	assert.False(t, resolver.AssertNotResolved(recorder, "checkout"))
+	assert.False(t, resolver.AssertApplied(recorder, "other"))
+	assert.False(t, resolver.AssertTracked(recorder, "refund"))
+	assert.Len(t, recorder.failures, 5)
+	assert.Contains(t, recorder.failures[0], `flag "other" was not resolved, resolved flags: [checkout]`)
+
+	resolver.Reset()
+	assert.Empty(t, resolver.Resolved())
+	assert.Empty(t, resolver.Tracked())
+}
+
+func TestResolveErrors(t *testing.T) {
+	resolver := NewResolver()
+	resolver.SetFlag("checkout", map[string]interface{}{"enabled": true})
+	resolver.SetError(errors.New("unavailable"))
+	confidence := resolver.Builder().Build()
+
+	detail := confidence.GetBoolFlag(context.Background(), "checkout.enabled", false)
+	assert.Equal(t, c.GeneralCode, detail.ErrorCode)
+
+	resolver.SetError(nil)
+	assert.True(t, confidence.GetBoolValue(context.Background(), "checkout.enabled", false))
+}
+
+func TestInvalidValuesPanic(t *testing.T) {
+	resolver := NewResolver()
+
+	assert.PanicsWithValue(t, `confidencetest: flag "checkout": can't infer the type of the nil value of checkout.color`,
+		func() { resolver.SetFlag("checkout", map[string]interface{}{"color": nil}) })
+	assert.Panics(t, func() { resolver.SetFlag("checkout", map[string]interface{}{"tags": []interface{}{}}) })
+	assert.Panics(t, func() { resolver.SetFlag("checkout", map[string]interface{}{"tags": []interface{}{1, "a"}}) })
+	assert.Panics(t, func() {
+		resolver.SetFlag("checkout", map[string]interface{}{"enabled": true}).
+			Override(map[string]interface{}{}, "other", map[string]interface{}{"enabled": "yes"})
+	})
+}
@@ -0,0 +1,276 @@
+package confidencetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+)
+
+// ErrUnmatched is the error, wrapped with the details of the request, of a Replayer receiving a resolve request
+// that wasn't recorded.
+var ErrUnmatched = errors.New("confidencetest: no recorded resolve matches the request")
+
+// Interaction is a resolve request recorded by a Recorder, with its response.
+type Interaction struct {
+	Request  RecordedRequest `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+// RecordedRequest is the part of a resolve request a Replayer matches requests on.
+type RecordedRequest struct {
+	Flags             []string               `json:"flags"`
+	EvaluationContext map[string]interface{} `json:"evaluation_context"`
+}
+
+// recording is the content of a recording file.
+type recording struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is a c.ResolveClient sending the resolve requests to another client, e.g. a c.HttpResolveClient, and
+// recording the successful ones with their responses, to be replayed by a Replayer.
+//
+//	recorder := confidencetest.NewRecorder(c.NewHttpResolveClient(config))
+//	confidence := c.NewConfidenceBuilder().SetAPIConfig(config).SetResolveClient(recorder).Build()
+//	// ... run the staging scenario ...
+//	err := recorder.Save("testdata/resolves.json")
+//
+// The recording holds the evaluation contexts as they were sent, strip personal data from them before they are
+// checked in.
+type Recorder struct {
+	client       c.ResolveClient
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder returns a Recorder sending the resolve requests to client.
+func NewRecorder(client c.ResolveClient) *Recorder {
+	return &Recorder{client: client}
+}
+
+// SendResolveRequest sends request to the recorded client and records its response, failed requests aren't
+// recorded.
+func (r *Recorder) SendResolveRequest(ctx context.Context, request c.ResolveRequest) (c.ResolveResponse, error) {
+	response, err := r.client.SendResolveRequest(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	payload, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		return response, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: RecordedRequest{
+			Flags:             append([]string{}, request.Flags...),
+			EvaluationContext: normalize(request.EvaluationContext),
+		},
+		Response: payload,
+	})
+	return response, nil
+}
+
+// Interactions returns the recorded interactions, in order.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction{}, r.interactions...)
+}
+
+// Write writes the recording to w, as JSON.
+func (r *Recorder) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(recording{Interactions: r.Interactions()})
+}
+
+// Save writes the recording to the file at path, replacing it if it exists.
+func (r *Recorder) Save(path string) error {
+	var buffer bytes.Buffer
+	if err := r.Write(&buffer); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0o644)
+}
+
+// ReplayOption configures how a Replayer matches the resolve requests with the recorded ones.
+type ReplayOption func(*replayConfig)
+
+type replayConfig struct {
+	ignoredKeys  map[string]bool
+	matchContext func(recorded, actual map[string]interface{}) bool
+}
+
+// IgnoreContextKeys ignores the evaluation context attributes named keys, at any depth, when matching requests,
+// e.g. timestamps or request ids that change between runs.
+func IgnoreContextKeys(keys ...string) ReplayOption {
+	return func(config *replayConfig) {
+		for _, key := range keys {
+			config.ignoredKeys[key] = true
+		}
+	}
+}
+
+// MatchContext matches the evaluation contexts of requests with match instead of requiring them to be equal. The
+// contexts are passed in their JSON form, without the attributes ignored with IgnoreContextKeys.
+func MatchContext(match func(recorded, actual map[string]interface{}) bool) ReplayOption {
+	return func(config *replayConfig) {
+		config.matchContext = match
+	}
+}
+
+// Replayer is a c.ResolveClient answering the resolve requests with the responses recorded by a Recorder, without
+// the network. A request is answered with the first recorded interaction for the same flags whose evaluation context
+// matches, see ReplayOption. Requests without a match fail with an error wrapping ErrUnmatched, which Confidence logs
+// and evaluates to the default value.
+type Replayer struct {
+	config       replayConfig
+	interactions []Interaction
+	mu           sync.Mutex
+	unmatched    []error
+}
+
+// LoadReplayer returns a Replayer of the recording saved at path.
+func LoadReplayer(path string, options ...ReplayOption) (*Replayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewReplayer(file, options...)
+}
+
+// NewReplayer returns a Replayer of the recording read from reader.
+func NewReplayer(reader io.Reader, options ...ReplayOption) (*Replayer, error) {
+	var recorded recording
+	if err := json.NewDecoder(reader).Decode(&recorded); err != nil {
+		return nil, fmt.Errorf("confidencetest: invalid recording: %w", err)
+	}
+	config := replayConfig{ignoredKeys: map[string]bool{}}
+	for _, option := range options {
+		option(&config)
+	}
+	if config.matchContext == nil {
+		config.matchContext = func(recorded, actual map[string]interface{}) bool {
+			return reflect.DeepEqual(recorded, actual)
+		}
+	}
+	return &Replayer{config: config, interactions: recorded.Interactions}, nil
+}
+
+// SendResolveRequest answers request with the matching recorded response.
+func (r *Replayer) SendResolveRequest(_ context.Context, request c.ResolveRequest) (c.ResolveResponse, error) {
+	actual := r.matchable(normalize(request.EvaluationContext))
+	var candidates []map[string]interface{}
+	for _, interaction := range r.interactions {
+		if !sameFlags(interaction.Request.Flags, request.Flags) {
+			continue
+		}
+		recorded := r.matchable(interaction.Request.EvaluationContext)
+		if !r.config.matchContext(recorded, actual) {
+			candidates = append(candidates, recorded)
+			continue
+		}
+		var response c.ResolveResponse
+		decoder := json.NewDecoder(bytes.NewReader(interaction.Response))
+		decoder.UseNumber()
+		if err := decoder.Decode(&response); err != nil {
+			return c.ResolveResponse{}, fmt.Errorf("confidencetest: invalid recorded response: %w", err)
+		}
+		response.Source = c.ResolveSourceLocal
+		return response, nil
+	}
+
+	err := unmatchedError(request.Flags, actual, candidates)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unmatched = append(r.unmatched, err)
+	return c.ResolveResponse{}, err
+}
+
+// Unmatched returns the errors of the requests that didn't match a recorded one, in order.
+func (r *Replayer) Unmatched() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error{}, r.unmatched...)
+}
+
+// matchable returns evaluationContext without the ignored attributes.
+func (r *Replayer) matchable(evaluationContext map[string]interface{}) map[string]interface{} {
+	if len(r.config.ignoredKeys) == 0 || evaluationContext == nil {
+		return evaluationContext
+	}
+	return withoutKeys(evaluationContext, r.config.ignoredKeys)
+}
+
+// withoutKeys returns a copy of value without the entries named keys, at any depth.
+func withoutKeys(value map[string]interface{}, keys map[string]bool) map[string]interface{} {
+	stripped := make(map[string]interface{}, len(value))
+	for key, child := range value {
+		if keys[key] {
+			continue
+		}
+		if nested, ok := child.(map[string]interface{}); ok {
+			child = withoutKeys(nested, keys)
+		}
+		stripped[key] = child
+	}
+	return stripped
+}
+
+// unmatchedError describes a request for flags with evaluationContext that matched none of the candidates, the
+// contexts recorded for the same flags.
+func unmatchedError(flags []string, evaluationContext map[string]interface{},
+	candidates []map[string]interface{}) error {
+	contextJson, _ := json.Marshal(evaluationContext)
+	if len(candidates) == 0 {
+		return fmt.Errorf("%w: no resolve of %v was recorded, context %s", ErrUnmatched, flags, contextJson)
+	}
+	closest := differingKeys(candidates[0], evaluationContext)
+	for _, candidate := range candidates[1:] {
+		if keys := differingKeys(candidate, evaluationContext); len(keys) < len(closest) {
+			closest = keys
+		}
+	}
+	return fmt.Errorf("%w: %d resolves of %v were recorded, the closest differs in %v, context %s",
+		ErrUnmatched, len(candidates), flags, closest, contextJson)
+}
+
+// differingKeys returns the sorted top level attributes that differ between a and b.
+func differingKeys(a, b map[string]interface{}) []string {
+	keys := []string{}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || !reflect.DeepEqual(value, other) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sameFlags reports whether a and b hold the same flags, in any order.
+func sameFlags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	return reflect.DeepEqual(sortedA, sortedB)
+}
@@ -0,0 +1,130 @@
+package confidencetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+)
+
+// record resolves the checkout flag for contexts through a Recorder and returns the recording.
+func record(t *testing.T, contexts ...map[string]interface{}) []byte {
+	resolver := NewResolver()
+	resolver.SetFlag("checkout", map[string]interface{}{"enabled": false, "max-items": 10}).
+		Override(map[string]interface{}{"country": "SE"}, "sweden", map[string]interface{}{"enabled": true})
+	recorder := NewRecorder(resolver)
+	confidence := c.NewConfidenceBuilder().SetAPIConfig(c.APIConfig{APIKey: ClientSecret}).
+		SetResolveClient(recorder).Build()
+	for _, evaluationContext := range contexts {
+		confidence.WithContext(evaluationContext).GetBoolFlag(context.Background(), "checkout.enabled", false)
+	}
+	var recording bytes.Buffer
+	assert.NoError(t, recorder.Write(&recording))
+	return recording.Bytes()
+}
+
+func TestReplaysRecordedResolves(t *testing.T) {
+	recording := record(t,
+		map[string]interface{}{"targeting_key": "user1", "country": "SE"},
+		map[string]interface{}{"targeting_key": "user2", "country": "NO"})
+	replayer, err := NewReplayer(bytes.NewReader(recording))
+	assert.NoError(t, err)
+	confidence := c.NewConfidenceBuilder().SetAPIConfig(c.APIConfig{APIKey: ClientSecret}).
+		SetResolveClient(replayer).Build()
+
+	swede := confidence.WithContext(map[string]interface{}{"targeting_key": "user1", "country": "SE"})
+	detail := swede.GetBoolFlag(context.Background(), "checkout.enabled", false)
+	assert.True(t, detail.Value)
+	assert.Equal(t, "flags/checkout/variants/sweden", detail.Variant)
+	assert.Equal(t, string(c.ResolveSourceLocal), detail.FlagMetadata[c.FlagMetadataSource])
+
+	other := confidence.WithContext(map[string]interface{}{"targeting_key": "user2", "country": "NO"})
+	assert.Equal(t, "flags/checkout/variants/default",
+		other.GetBoolFlag(context.Background(), "checkout.enabled", true).Variant)
+	assert.Equal(t, int64(10), other.GetIntValue(context.Background(), "checkout.max-items", 0))
+	assert.Empty(t, replayer.Unmatched())
+}
+
+func TestReplayerReportsUnmatchedRequests(t *testing.T) {
+	recording := record(t,
+		map[string]interface{}{"targeting_key": "user1", "country": "SE"},
+		map[string]interface{}{"targeting_key": "user2", "country": "NO"})
+	replayer, err := NewReplayer(bytes.NewReader(recording))
+	assert.NoError(t, err)
+
+	_, err = replayer.SendResolveRequest(context.Background(), c.ResolveRequest{Flags: []string{"flags/checkout"},
+		EvaluationContext: map[string]interface{}{"targeting_key": "user1", "country": "NO"}})
+	assert.True(t, errors.Is(err, ErrUnmatched))
+	assert.Equal(t, "confidencetest: no recorded resolve matches the request: 2 resolves of [flags/checkout] were "+
+		`recorded, the closest differs in [country], context {"country":"NO","targeting_key":"user1"}`, err.Error())
+
+	_, err = replayer.SendResolveRequest(context.Background(), c.ResolveRequest{Flags: []string{"flags/other"},
+		EvaluationContext: map[string]interface{}{}})
+	assert.Equal(t, "confidencetest: no recorded resolve matches the request: no resolve of [flags/other] was "+
+		"recorded, context {}", err.Error())
+	assert.Len(t, replayer.Unmatched(), 2)
+
+	confidence := c.NewConfidenceBuilder().SetAPIConfig(c.APIConfig{APIKey: ClientSecret}).
+		SetResolveClient(replayer).Build()
+	assert.Equal(t, c.GeneralCode, confidence.GetBoolFlag(context.Background(), "checkout.enabled", false).ErrorCode)
+}
+
+func TestReplayerIgnoresVolatileKeys(t *testing.T) {
+	recording := record(t, map[string]interface{}{"targeting_key": "user1", "country": "SE",
+		"session": map[string]interface{}{"id": "a", "started_at": "2024-01-01T00:00:00Z"}})
+	replayer, err := NewReplayer(bytes.NewReader(recording), IgnoreContextKeys("started_at", "request_id"))
+	assert.NoError(t, err)
+
+	_, err = replayer.SendResolveRequest(context.Background(), c.ResolveRequest{Flags: []string{"flags/checkout"},
+		EvaluationContext: map[string]interface{}{"targeting_key": "user1", "country": "SE", "request_id": 42,
+			"session": map[string]interface{}{"id": "a", "started_at": "2024-06-01T00:00:00Z"}}})
+	assert.NoError(t, err)
+
+	_, err = replayer.SendResolveRequest(context.Background(), c.ResolveRequest{Flags: []string{"flags/checkout"},
+		EvaluationContext: map[string]interface{}{"targeting_key": "user1", "country": "SE",
+			"session": map[string]interface{}{"id": "b"}}})
+	assert.ErrorIs(t, err, ErrUnmatched)
+}
+
+func TestReplayerMatchesWithCustomMatchers(t *testing.T) {
+	recording := record(t, map[string]interface{}{"targeting_key": "user1", "country": "SE"})
+	sameCountry := func(recorded, actual map[string]interface{}) bool {
+		return recorded["country"] == actual["country"]
+	}
+	replayer, err := NewReplayer(bytes.NewReader(recording), MatchContext(sameCountry))
+	assert.NoError(t, err)
+
+	response, err := replayer.SendResolveRequest(context.Background(), c.ResolveRequest{Flags: []string{"flags/checkout"},
+		EvaluationContext: map[string]interface{}{"targeting_key": "user9", "country": "SE"}})
+
+	assert.NoError(t, err)
+	assert.Len(t, response.ResolvedFlags, 1)
+}
+
+func TestRecordingsAreSavedToFiles(t *testing.T) {
+	resolver := NewResolver()
+	resolver.SetFlag("checkout", map[string]interface{}{"enabled": true})
+	recorder := NewRecorder(resolver)
+	request := c.ResolveRequest{Flags: []string{"flags/checkout"},
+		EvaluationContext: map[string]interface{}{"targeting_key": "user1"}}
+	_, err := recorder.SendResolveRequest(context.Background(), request)
+	assert.NoError(t, err)
+	resolver.SetError(errors.New("unavailable"))
+	_, err = recorder.SendResolveRequest(context.Background(), request)
+	assert.Error(t, err)
+	assert.Len(t, recorder.Interactions(), 1)
+	path := filepath.Join(t.TempDir(), "resolves.json")
+
+	assert.NoError(t, recorder.Save(path))
+	replayer, err := LoadReplayer(path)
+
+	assert.NoError(t, err)
+	_, err = replayer.SendResolveRequest(context.Background(), request)
+	assert.NoError(t, err)
+	_, err = LoadReplayer(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package confidencetest
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// mustInferSchema returns the flag schema of value, and panics if it can't be inferred.
+func mustInferSchema(flag string, value map[string]interface{}) map[string]interface{} {
+	schema, err := inferFields(reflect.ValueOf(value), flag)
+	if err != nil {
+		panic(fmt.Sprintf("confidencetest: flag %q: %v", flag, err))
+	}
+	return schema
+}
+
+// inferFields returns the schemas of the entries of the map value, by key.
+func inferFields(value reflect.Value, path string) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	iterator := value.MapRange()
+	for iterator.Next() {
+		key := fmt.Sprint(iterator.Key().Interface())
+		schema, err := inferSchema(iterator.Value(), path+"."+key)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = schema
+	}
+	return fields, nil
+}
+
+// inferSchema returns the property schema of value, e.g. {"boolSchema": {}}.
+func inferSchema(value reflect.Value, path string) (map[string]interface{}, error) {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil, fmt.Errorf("can't infer the type of the nil value of %s", path)
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%s is a map without string keys", path)
+		}
+		fields, err := inferFields(value, path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"structSchema": map[string]interface{}{"schema": fields}}, nil
+	case reflect.Slice, reflect.Array:
+		elementSchema, err := inferElementSchema(value, path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"listSchema": map[string]interface{}{"elementSchema": elementSchema}}, nil
+	}
+	return inferTypeSchema(value.Type(), path)
+}
+
+// inferElementSchema returns the schema of the elements of the list value, which must all have the same schema.
+func inferElementSchema(value reflect.Value, path string) (map[string]interface{}, error) {
+	if value.Len() == 0 {
+		return inferTypeSchema(value.Type().Elem(), path+"[]")
+	}
+	var elementSchema map[string]interface{}
+	for i := 0; i < value.Len(); i++ {
+		schema, err := inferSchema(value.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		if elementSchema, err = mergeSchema(elementSchema, schema); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return elementSchema, nil
+}
+
+// inferTypeSchema returns the property schema of the values of the scalar type t.
+func inferTypeSchema(t reflect.Type, path string) (map[string]interface{}, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"boolSchema": map[string]interface{}{}}, nil
+	case reflect.String:
+		return map[string]interface{}{"stringSchema": map[string]interface{}{}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"intSchema": map[string]interface{}{}}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"doubleSchema": map[string]interface{}{}}, nil
+	}
+	return nil, fmt.Errorf("can't infer the schema of %s of type %s", path, t)
+}
+
+// mergeSchema returns the property schema covering the values of both a and b. A nil schema covers no value.
+func mergeSchema(a, b map[string]interface{}) (map[string]interface{}, error) {
+	if a == nil {
+		return b, nil
+	}
+	if kind(a) != kind(b) {
+		return nil, fmt.Errorf("conflicting types %s and %s", kind(a), kind(b))
+	}
+	if structA, ok := a["structSchema"].(map[string]interface{}); ok {
+		structB := b["structSchema"].(map[string]interface{})
+		fields, err := mergeFields(structA["schema"].(map[string]interface{}), structB["schema"].(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"structSchema": map[string]interface{}{"schema": fields}}, nil
+	}
+	if listA, ok := a["listSchema"].(map[string]interface{}); ok {
+		listB := b["listSchema"].(map[string]interface{})
+		elementSchema, err := mergeSchema(listA["elementSchema"].(map[string]interface{}),
+			listB["elementSchema"].(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"listSchema": map[string]interface{}{"elementSchema": elementSchema}}, nil
+	}
+	return a, nil
+}
+
+// mergeFields returns the field schemas covering the fields of both a and b.
+func mergeFields(a, b map[string]interface{}) (map[string]interface{}, error) {
+	merged := map[string]interface{}{}
+	for key, schema := range a {
+		merged[key] = schema
+	}
+	for key, schema := range b {
+		existing, _ := merged[key].(map[string]interface{})
+		mergedSchema, err := mergeSchema(existing, schema.(map[string]interface{}))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		merged[key] = mergedSchema
+	}
+	return merged, nil
+}
+
+// kind returns the kind of the property schema, e.g. "boolSchema".
+func kind(schema map[string]interface{}) string {
+	for _, key := range []string{"boolSchema", "stringSchema", "intSchema", "doubleSchema", "structSchema",
+		"listSchema"} {
+		if _, ok := schema[key]; ok {
+			return key
+		}
+	}
+	return ""
+}
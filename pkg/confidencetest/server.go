@@ -0,0 +1,271 @@
+package confidencetest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"google.golang.org/protobuf/proto"
+)
+
+// Endpoints served by a Server.
+const (
+	EndpointResolve = "/v1/flags:resolve"
+	EndpointApply   = "/v1/flags:apply"
+	EndpointPublish = "/v1/events:publish"
+)
+
+// TelemetryHeader is the header the resolve requests carry the telemetry of the SDK in.
+const TelemetryHeader = "X-CONFIDENCE-TELEMETRY"
+
+// Failure is a failure injected in the responses of an endpoint of a Server.
+type Failure struct {
+	// StatusCode is the status of the failed responses, e.g. http.StatusServiceUnavailable, with an error body.
+	StatusCode int
+	// Malformed makes the failed responses succeed with a body that isn't valid JSON.
+	Malformed bool
+	// Hang makes the failed requests hang until the client gives up, e.g. to trigger client timeouts.
+	Hang bool
+	// Times is the number of requests that fail, zero fails every request until the failures are cleared.
+	Times int
+}
+
+// Server is a mock of the Confidence resolver and events services serving over HTTP, to test the HTTP path of
+// Confidence end to end. Flags are resolved by its Resolver, which also records the requests received.
+//
+//	server := confidencetest.NewServer(nil)
+//	defer server.Close()
+//	server.Resolver.SetFlag("checkout", map[string]interface{}{"enabled": true})
+//	confidence := server.Builder().Build()
+type Server struct {
+	// URL is the base URL of the server, used for both the resolver and the events services.
+	URL string
+	// Resolver resolves the flags and records the resolve requests, applied flags and tracked events.
+	Resolver *Resolver
+
+	server    *httptest.Server
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu        sync.Mutex
+	latency   time.Duration
+	failures  map[string]*Failure
+	telemetry []*c.ProtoMonitoring
+}
+
+// NewServer starts a Server resolving flags with resolver, or with a new Resolver if resolver is nil. The server
+// must be closed when the test ends.
+func NewServer(resolver *Resolver) *Server {
+	if resolver == nil {
+		resolver = NewResolver()
+	}
+	s := &Server{Resolver: resolver, closed: make(chan struct{}), failures: map[string]*Failure{}}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close releases the hanging requests and shuts the server down.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+	s.server.Close()
+}
+
+// APIConfig returns the default config of Confidence, with the resolver and events services set to s.
+func (s *Server) APIConfig() *c.APIConfig {
+	return c.NewAPIConfigWithUrl(ClientSecret, s.URL).WithEventsBaseUrl(s.URL)
+}
+
+// Builder returns a c.ConfidenceBuilder resolving flags and tracking events through s over HTTP.
+func (s *Server) Builder() c.ConfidenceBuilder {
+	return c.NewConfidenceBuilder().SetAPIConfig(*s.APIConfig())
+}
+
+// SetLatency delays every response of s by latency.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// SetFailure makes the requests to endpoint fail with failure, e.g. EndpointResolve.
+func (s *Server) SetFailure(endpoint string, failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = &failure
+}
+
+// ClearFailures removes the failures set with SetFailure.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = map[string]*Failure{}
+}
+
+// Telemetry returns the decoded telemetry headers of the resolve requests received, in order. Requests without
+// telemetry, e.g. when it is disabled, are left out.
+func (s *Server) Telemetry() []*c.ProtoMonitoring {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*c.ProtoMonitoring{}, s.telemetry...)
+}
+
+// DecodeTelemetryHeader decodes the value of the telemetry header of a resolve request.
+func DecodeTelemetryHeader(value string) (*c.ProtoMonitoring, error) {
+	payload, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("telemetry header is not base64: %w", err)
+	}
+	monitoring := &c.ProtoMonitoring{}
+	if err := proto.Unmarshal(payload, monitoring); err != nil {
+		return nil, fmt.Errorf("telemetry header is not a ProtoMonitoring: %w", err)
+	}
+	return monitoring, nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var handle func(ctx context.Context, body []byte) (interface{}, error)
+	switch r.URL.Path {
+	case EndpointResolve:
+		handle = s.resolve
+		if header := r.Header.Get(TelemetryHeader); header != "" {
+			if err := s.recordTelemetry(header); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+	case EndpointApply:
+		handle = s.apply
+	case EndpointPublish:
+		handle = s.publish
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown endpoint %s", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed", r.Method))
+		return
+	}
+
+	latency, failure := s.nextResponse(r.URL.Path)
+	select {
+	case <-time.After(latency):
+	case <-r.Context().Done():
+		return
+	case <-s.closed:
+		return
+	}
+	if failure.Hang {
+		select {
+		case <-r.Context().Done():
+		case <-s.closed:
+		}
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if failure.StatusCode != 0 {
+		writeError(w, failure.StatusCode, fmt.Errorf("injected failure"))
+		return
+	}
+	if failure.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"resolvedFlags": [`)
+		return
+	}
+	response, err := handle(r.Context(), body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// nextResponse returns the latency and the failure of the next response of endpoint, and counts the failure.
+func (s *Server) nextResponse(endpoint string) (time.Duration, Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failure, ok := s.failures[endpoint]
+	if !ok {
+		return s.latency, Failure{}
+	}
+	if failure.Times > 0 {
+		failure.Times--
+		if failure.Times == 0 {
+			delete(s.failures, endpoint)
+		}
+	}
+	return s.latency, *failure
+}
+
+func (s *Server) recordTelemetry(header string) error {
+	monitoring, err := DecodeTelemetryHeader(header)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.telemetry = append(s.telemetry, monitoring)
+	return nil
+}
+
+func (s *Server) resolve(ctx context.Context, body []byte) (interface{}, error) {
+	var request c.ResolveRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	return s.Resolver.SendResolveRequest(ctx, request)
+}
+
+// applyRequest is the body of a request to EndpointApply.
+type applyRequest struct {
+	Flags []struct {
+		Flag string `json:"flag"`
+	} `json:"flags"`
+	ResolveToken string `json:"resolveToken"`
+}
+
+func (s *Server) apply(_ context.Context, body []byte) (interface{}, error) {
+	var request applyRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	flags := []string{}
+	for _, flag := range request.Flags {
+		flags = append(flags, flag.Flag)
+	}
+	s.Resolver.mu.Lock()
+	defer s.Resolver.mu.Unlock()
+	s.Resolver.apply(flags)
+	return struct{}{}, nil
+}
+
+func (s *Server) publish(ctx context.Context, body []byte) (interface{}, error) {
+	var request c.EventBatchRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	return struct{}{}, s.Resolver.upload(ctx, request)
+}
+
+// writeError writes an error response in the format of the Confidence services.
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    status,
+		"message": err.Error(),
+	})
+}
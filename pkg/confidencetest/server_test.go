@@ -0,0 +1,121 @@
+package confidencetest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerResolvesOverHttp(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.Resolver.SetFlag("checkout", map[string]interface{}{"enabled": true, "max-items": 10})
+	confidence := server.Builder().Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	detail := confidence.GetBoolFlag(context.Background(), "checkout.enabled", false)
+	assert.True(t, detail.Value)
+	assert.Equal(t, c.TargetingMatchReason, detail.Reason)
+	assert.Equal(t, int64(10), confidence.GetIntValue(context.Background(), "checkout.max-items", 0))
+	assert.Equal(t, c.FlagNotFoundCode, confidence.GetBoolFlag(context.Background(), "missing.enabled", false).ErrorCode)
+
+	server.Resolver.AssertResolvedWithContext(t, "checkout", map[string]interface{}{"targeting_key": "user1"})
+	server.Resolver.AssertApplied(t, "checkout")
+	assert.Equal(t, ClientSecret, server.Resolver.Requests()[0].ClientSecret)
+}
+
+func TestServerDecodesTelemetryHeaders(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.Resolver.SetFlag("checkout", map[string]interface{}{"enabled": true})
+	confidence := server.Builder().Build()
+
+	confidence.GetBoolFlag(context.Background(), "checkout.enabled", false)
+	confidence.GetBoolFlag(context.Background(), "checkout.enabled", false)
+
+	telemetry := server.Telemetry()
+	assert.Len(t, telemetry, 2)
+	assert.Equal(t, c.ProtoPlatform_PROTO_PLATFORM_GO, telemetry[0].Platform)
+	assert.Empty(t, telemetry[0].LibraryTraces[0].Traces)
+	traces := telemetry[1].LibraryTraces[0].Traces
+	assert.Len(t, traces, 1)
+	assert.Equal(t, c.ProtoLibraryTraces_PROTO_TRACE_ID_RESOLVE_LATENCY, traces[0].Id)
+	assert.Equal(t, c.ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_SUCCESS,
+		traces[0].GetRequestTrace().Status)
+
+	_, err := DecodeTelemetryHeader("not base64!")
+	assert.Error(t, err)
+}
+
+func TestServerPublishesEvents(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	confidence := server.Builder().Build()
+
+	confidence.Track(context.Background(), "purchase", map[string]interface{}{"amount": 10})
+	assert.NoError(t, confidence.Flush(context.Background()))
+
+	server.Resolver.AssertTracked(t, "purchase")
+}
+
+func TestServerApplies(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+
+	response, err := http.Post(server.URL+EndpointApply, "application/json",
+		bytes.NewBufferString(`{"flags": [{"flag": "flags/checkout"}], "resolveToken": "token"}`))
+	assert.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	server.Resolver.AssertApplied(t, "checkout")
+	server.Resolver.AssertNotResolved(t, "checkout")
+}
+
+func TestServerInjectsFailures(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.Resolver.SetFlag("checkout", map[string]interface{}{"enabled": true})
+	confidence := server.Builder().
+		SetAPIConfig(*server.APIConfig().WithResolveTimeout(50 * time.Millisecond)).
+		Build()
+
+	server.SetFailure(EndpointResolve, Failure{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	assert.Equal(t, c.GeneralCode, confidence.GetBoolFlag(context.Background(), "checkout.enabled", false).ErrorCode)
+	assert.True(t, confidence.GetBoolValue(context.Background(), "checkout.enabled", false))
+
+	server.SetFailure(EndpointResolve, Failure{Malformed: true})
+	assert.Equal(t, c.GeneralCode, confidence.GetBoolFlag(context.Background(), "checkout.enabled", false).ErrorCode)
+
+	server.SetFailure(EndpointResolve, Failure{Hang: true})
+	assert.Equal(t, c.TimeoutCode, confidence.GetBoolFlag(context.Background(), "checkout.enabled", false).ErrorCode)
+
+	server.ClearFailures()
+	assert.True(t, confidence.GetBoolValue(context.Background(), "checkout.enabled", false))
+	traces := server.Telemetry()[len(server.Telemetry())-1].LibraryTraces[0].Traces
+	assert.Equal(t, c.ProtoLibraryTraces_ProtoTrace_ProtoRequestTrace_PROTO_STATUS_TIMEOUT,
+		traces[len(traces)-1].GetRequestTrace().Status)
+
+	server.SetFailure(EndpointPublish, Failure{StatusCode: http.StatusInternalServerError})
+	confidence.Track(context.Background(), "purchase", map[string]interface{}{})
+	assert.NoError(t, confidence.Flush(context.Background()))
+	assert.Empty(t, server.Resolver.Tracked())
+}
+
+func TestServerLatency(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	server.Resolver.SetFlag("checkout", map[string]interface{}{"enabled": true})
+	server.SetLatency(50 * time.Millisecond)
+	confidence := server.Builder().Build()
+
+	start := time.Now()
+	assert.True(t, confidence.GetBoolValue(context.Background(), "checkout.enabled", false))
+
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
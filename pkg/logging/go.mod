@@ -0,0 +1,21 @@
+module github.com/spotify/confidence-sdk-go/pkg/logging
+
+go 1.21
+
+require (
+	github.com/go-logr/logr v1.4.2
+	github.com/spotify/confidence-sdk-go v0.5.0 // x-release-please-version
+	github.com/stretchr/testify v1.9.0
+	go.uber.org/zap v1.27.0
+	go.uber.org/zap/exp v0.2.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/spotify/confidence-sdk-go => ../..
@@ -0,0 +1,28 @@
+// Package logging adapts the loggers of other logging libraries to the *slog.Logger taken by
+// ConfidenceBuilder.SetLogger.
+//
+//	confidence := c.NewConfidenceBuilder().
+//		SetAPIConfig(*config).
+//		SetLogger(logging.FromZap(zapLogger)).
+//		Build()
+package logging
+
+import (
+	"log/slog"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/exp/zapslog"
+)
+
+// FromLogr returns a *slog.Logger writing to logger. Debug records are written at V(4) of logger, info and warning
+// records at V(0), and error records through logger.Error.
+func FromLogr(logger logr.Logger) *slog.Logger {
+	return slog.New(logr.ToSlogHandler(logger))
+}
+
+// FromZap returns a *slog.Logger writing to logger, with the levels of the records mapped to the zap levels. The
+// records are filtered by the level of logger.
+func FromZap(logger *zap.Logger) *slog.Logger {
+	return slog.New(zapslog.NewHandler(logger.Core(), nil))
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type staticResolveClient struct {
+	response c.ResolveResponse
+}
+
+func (r staticResolveClient) SendResolveRequest(_ context.Context, _ c.ResolveRequest) (c.ResolveResponse, error) {
+	return r.response, nil
+}
+
+func TestFromLogr(t *testing.T) {
+	var logs bytes.Buffer
+	logger := funcr.New(func(prefix, args string) {
+		logs.WriteString(args + "\n")
+	}, funcr.Options{Verbosity: 4})
+
+	c.NewConfidenceBuilder().
+		SetAPIConfig(c.APIConfig{APIKey: "my-client-secret-1234"}).
+		SetResolveClient(staticResolveClient{}).
+		SetLogger(FromLogr(logger)).
+		Build()
+	FromLogr(logger).Debug("Flag evaluated", c.LogKeyFlag, "test-flag")
+
+	assert.Contains(t, logs.String(), `"msg"="Confidence created"`)
+	assert.Contains(t, logs.String(), "****1234")
+	assert.NotContains(t, logs.String(), "my-client-secret")
+	assert.Contains(t, logs.String(), `"level"=4 "msg"="Flag evaluated" "flag"="test-flag"`)
+}
+
+func TestFromZap(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	c.NewConfidenceBuilder().
+		SetAPIConfig(c.APIConfig{APIKey: "my-client-secret-1234"}).
+		SetResolveClient(staticResolveClient{}).
+		SetLogger(FromZap(zap.New(core))).
+		Build()
+	FromZap(zap.New(core)).Debug("Flag evaluated", c.LogKeyFlag, "test-flag")
+	FromZap(zap.New(core)).Warn("Resolve failed", c.LogKeyError, "unavailable")
+
+	entries := logs.All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "Confidence created", entries[0].Message)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Contains(t, fmt.Sprint(entries[0].ContextMap()[c.LogKeyConfig]), "****1234")
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, map[string]interface{}{c.LogKeyError: "unavailable"}, entries[1].ContextMap())
+}
@@ -0,0 +1,193 @@
+// Package otel reports the flag evaluations, resolve requests and event uploads of a Confidence as OpenTelemetry
+// spans and metrics.
+//
+//	instrumentation, err := otel.NewInstrumentation()
+//	if err != nil {
+//		...
+//	}
+//	confidence := c.NewConfidenceBuilder().SetAPIConfig(*config).AddInstrumentation(instrumentation).Build()
+//
+// Evaluation spans and metrics follow the OpenTelemetry semantic conventions for feature flags.
+package otel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	global "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/spotify/confidence-sdk-go/pkg/otel"
+	providerName        = "Confidence"
+)
+
+// Attributes of the spans and metrics, the feature_flag and error attributes follow the semantic conventions.
+const (
+	FeatureFlagKey          = attribute.Key("feature_flag.key")
+	FeatureFlagProviderName = attribute.Key("feature_flag.provider_name")
+	FeatureFlagVariant      = attribute.Key("feature_flag.result.variant")
+	FeatureFlagReason       = attribute.Key("feature_flag.result.reason")
+	ErrorType               = attribute.Key("error.type")
+	// Source tells whether an evaluation was served from the network, the cache or a local resolver.
+	Source = attribute.Key("confidence.source")
+	// Domain is the OpenFeature domain of the provider a flag was evaluated through, see c.SdkInfo.
+	Domain = attribute.Key("confidence.domain")
+	// Status is the outcome of a resolve request: success, error or timeout.
+	Status = attribute.Key("confidence.resolve.status")
+	// EventCount is the number of events in an uploaded batch.
+	EventCount = attribute.Key("confidence.events.count")
+)
+
+// Resolve request statuses, they match the statuses of the Confidence telemetry.
+const (
+	StatusSuccess = c.ResolveStatusSuccess
+	StatusError   = c.ResolveStatusError
+	StatusTimeout = c.ResolveStatusTimeout
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures an Instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider spans are created with, the global one is used by default.
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(config *config) {
+		config.tracerProvider = tracerProvider
+	}
+}
+
+// WithMeterProvider sets the MeterProvider metrics are recorded with, the global one is used by default.
+func WithMeterProvider(meterProvider metric.MeterProvider) Option {
+	return func(config *config) {
+		config.meterProvider = meterProvider
+	}
+}
+
+// Instrumentation is a c.Instrumentation creating spans and recording metrics. It records:
+//
+//   - confidence.evaluations, the number of flag evaluations by reason, source and domain. The share of evaluations
+//     with the cache source is the cache hit ratio.
+//   - confidence.evaluation.errors, the number of failed flag evaluations by error type.
+//   - confidence.resolve.duration, a histogram of the resolve request latency by status.
+//   - confidence.events.pending, the number of event batches being uploaded.
+type Instrumentation struct {
+	tracer              trace.Tracer
+	evaluations         metric.Int64Counter
+	evaluationErrors    metric.Int64Counter
+	resolveDuration     metric.Float64Histogram
+	pendingEventUploads metric.Int64UpDownCounter
+}
+
+// NewInstrumentation creates the instruments of an Instrumentation, it fails if the MeterProvider rejects them.
+func NewInstrumentation(options ...Option) (*Instrumentation, error) {
+	config := config{
+		tracerProvider: global.GetTracerProvider(),
+		meterProvider:  global.GetMeterProvider(),
+	}
+	for _, option := range options {
+		option(&config)
+	}
+
+	meter := config.meterProvider.Meter(instrumentationName, metric.WithInstrumentationVersion(c.SDK_VERSION))
+	evaluations, err := meter.Int64Counter("confidence.evaluations",
+		metric.WithDescription("Number of flag evaluations."), metric.WithUnit("{evaluation}"))
+	if err != nil {
+		return nil, err
+	}
+	evaluationErrors, err := meter.Int64Counter("confidence.evaluation.errors",
+		metric.WithDescription("Number of failed flag evaluations."), metric.WithUnit("{evaluation}"))
+	if err != nil {
+		return nil, err
+	}
+	resolveDuration, err := meter.Float64Histogram("confidence.resolve.duration",
+		metric.WithDescription("Duration of the requests to the flag resolver."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	pendingEventUploads, err := meter.Int64UpDownCounter("confidence.events.pending",
+		metric.WithDescription("Number of event batches being uploaded."), metric.WithUnit("{batch}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumentation{
+		tracer: config.tracerProvider.Tracer(instrumentationName,
+			trace.WithInstrumentationVersion(c.SDK_VERSION)),
+		evaluations:         evaluations,
+		evaluationErrors:    evaluationErrors,
+		resolveDuration:     resolveDuration,
+		pendingEventUploads: pendingEventUploads,
+	}, nil
+}
+
+func (i *Instrumentation) StartEvaluation(ctx context.Context, flag string) (context.Context, func(c.ResolutionDetail)) {
+	ctx, span := i.tracer.Start(ctx, "confidence.evaluate", trace.WithAttributes(
+		FeatureFlagKey.String(flag),
+		FeatureFlagProviderName.String(providerName),
+	))
+	return ctx, func(detail c.ResolutionDetail) {
+		if detail.Variant != "" {
+			span.SetAttributes(FeatureFlagVariant.String(detail.Variant))
+		}
+		attributes := []attribute.KeyValue{FeatureFlagReason.String(strings.ToLower(string(detail.Reason)))}
+		if source, ok := detail.FlagMetadata[c.FlagMetadataSource].(string); ok {
+			attributes = append(attributes, Source.String(source))
+		}
+		if domain, ok := detail.FlagMetadata[c.FlagMetadataDomain].(string); ok {
+			attributes = append(attributes, Domain.String(domain))
+		}
+		span.SetAttributes(attributes...)
+		i.evaluations.Add(ctx, 1, metric.WithAttributes(attributes...))
+
+		if detail.ErrorCode != "" {
+			errorType := ErrorType.String(strings.ToLower(string(detail.ErrorCode)))
+			span.SetAttributes(errorType)
+			span.SetStatus(codes.Error, detail.ErrorMessage)
+			i.evaluationErrors.Add(ctx, 1, metric.WithAttributes(errorType))
+		}
+		span.End()
+	}
+}
+
+func (i *Instrumentation) StartResolveRequest(ctx context.Context,
+	request c.ResolveRequest) (context.Context, func(c.ResolveResponse, error)) {
+	ctx, span := i.tracer.Start(ctx, "confidence.resolve", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(FeatureFlagKey.StringSlice(request.Flags)))
+	startTime := time.Now()
+	return ctx, func(_ c.ResolveResponse, err error) {
+		status := c.ResolveStatus(err)
+		i.resolveDuration.Record(ctx, time.Since(startTime).Seconds(), metric.WithAttributes(Status.String(status)))
+		span.SetAttributes(Status.String(status))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func (i *Instrumentation) StartEventUpload(ctx context.Context,
+	request c.EventBatchRequest) (context.Context, func(error)) {
+	ctx, span := i.tracer.Start(ctx, "confidence.upload_events", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(EventCount.Int(len(request.Events))))
+	i.pendingEventUploads.Add(ctx, 1)
+	return ctx, func(err error) {
+		i.pendingEventUploads.Add(ctx, -1)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
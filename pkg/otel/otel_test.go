@@ -0,0 +1,145 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type staticResolveClient struct {
+	response c.ResolveResponse
+	err      error
+}
+
+func (r staticResolveClient) SendResolveRequest(_ context.Context, _ c.ResolveRequest) (c.ResolveResponse, error) {
+	return r.response, r.err
+}
+
+func flagResponse(t *testing.T) c.ResolveResponse {
+	var response c.ResolveResponse
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"resolvedFlags": [{
+			"flag": "flags/test-flag",
+			"variant": "flags/test-flag/variants/treatment",
+			"value": {"enabled": true},
+			"flagSchema": {"schema": {"enabled": {"boolSchema": {}}}},
+			"reason": "RESOLVE_REASON_MATCH"
+		}],
+		"resolveToken": ""
+	}`), &response))
+	return response
+}
+
+func newInstrumentedConfidence(t *testing.T, client c.ResolveClient) (c.Confidence, *tracetest.SpanRecorder,
+	*sdkmetric.ManualReader) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	instrumentation, err := NewInstrumentation(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	assert.NoError(t, err)
+	confidence := c.NewConfidenceBuilder().
+		SetAPIConfig(c.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(client).
+		AddInstrumentation(instrumentation).
+		Build()
+	confidence.PutContext("targeting_key", "user1")
+	return confidence, spans, reader
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func sumOf(t *testing.T, reader *sdkmetric.ManualReader, name string) metricdata.Sum[int64] {
+	var metrics metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &metrics))
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				return m.Data.(metricdata.Sum[int64])
+			}
+		}
+	}
+	t.Fatalf("metric %s not recorded", name)
+	return metricdata.Sum[int64]{}
+}
+
+func TestEvaluationIsTracedWithSemanticConventions(t *testing.T) {
+	confidence, spans, reader := newInstrumentedConfidence(t, staticResolveClient{response: flagResponse(t)})
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.enabled", false)
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 2)
+	resolve, evaluate := ended[0], ended[1]
+	assert.Equal(t, "confidence.resolve", resolve.Name())
+	assert.Equal(t, evaluate.SpanContext().SpanID(), resolve.Parent().SpanID())
+	assert.Equal(t, StatusSuccess, spanAttributes(resolve)[Status].AsString())
+
+	assert.Equal(t, "confidence.evaluate", evaluate.Name())
+	attributes := spanAttributes(evaluate)
+	assert.Equal(t, "test-flag.enabled", attributes[FeatureFlagKey].AsString())
+	assert.Equal(t, "flags/test-flag/variants/treatment", attributes[FeatureFlagVariant].AsString())
+	assert.Equal(t, "targeting_match", attributes[FeatureFlagReason].AsString())
+	assert.Equal(t, "network", attributes[Source].AsString())
+	assert.NotContains(t, attributes, Domain)
+
+	evaluations := sumOf(t, reader, "confidence.evaluations")
+	assert.Len(t, evaluations.DataPoints, 1)
+	assert.Equal(t, int64(1), evaluations.DataPoints[0].Value)
+}
+
+func TestFailedEvaluationIsReportedAsError(t *testing.T) {
+	confidence, spans, reader := newInstrumentedConfidence(t,
+		staticResolveClient{err: context.DeadlineExceeded})
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.enabled", false)
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 2)
+	assert.Equal(t, StatusTimeout, spanAttributes(ended[0])[Status].AsString())
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+	assert.Equal(t, "timeout", spanAttributes(ended[1])[ErrorType].AsString())
+
+	errorsByType := sumOf(t, reader, "confidence.evaluation.errors")
+	assert.Len(t, errorsByType.DataPoints, 1)
+	errorType, _ := errorsByType.DataPoints[0].Attributes.Value(ErrorType)
+	assert.Equal(t, "timeout", errorType.AsString())
+}
+
+func TestEventUploadsAreTraced(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	instrumentation, err := NewInstrumentation(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	assert.NoError(t, err)
+
+	_, done := instrumentation.StartEventUpload(context.Background(), c.EventBatchRequest{Events: []c.Event{{}}})
+	pending := sumOf(t, reader, "confidence.events.pending")
+	done(errors.New("unavailable"))
+
+	assert.Equal(t, int64(1), pending.DataPoints[0].Value)
+	assert.Equal(t, int64(0), sumOf(t, reader, "confidence.events.pending").DataPoints[0].Value)
+	ended := spans.Ended()
+	assert.Len(t, ended, 1)
+	assert.Equal(t, "confidence.upload_events", ended[0].Name())
+	assert.Equal(t, int64(1), spanAttributes(ended[0])[EventCount].AsInt64())
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+}
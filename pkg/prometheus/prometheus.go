@@ -0,0 +1,151 @@
+// Package prometheus exposes the health of a Confidence as Prometheus metrics.
+//
+//	metrics, err := prometheus.Register(prom.DefaultRegisterer)
+//	if err != nil {
+//		...
+//	}
+//	confidence := c.NewConfidenceBuilder().SetAPIConfig(*config).AddInstrumentation(metrics).Build()
+//
+// The same Metrics can instrument several Confidence instances, their metrics are added up.
+package prometheus
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+)
+
+const namespace = "confidence"
+
+// Resolve request statuses, they match the statuses of the Confidence telemetry.
+const (
+	StatusSuccess = c.ResolveStatusSuccess
+	StatusError   = c.ResolveStatusError
+	StatusTimeout = c.ResolveStatusTimeout
+	// StatusCached is the status of evaluations served from the flag cache, without a request.
+	StatusCached = "cached"
+)
+
+// Event upload outcomes.
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Metrics is a c.TelemetryInstrumentation recording the following metrics:
+//
+//   - confidence_resolve_requests_total, the number of resolves by status.
+//   - confidence_resolve_request_duration_seconds, a histogram of the resolve request latency by status.
+//   - confidence_flag_evaluations_total, the number of flag evaluations by flag, reason and OpenFeature domain, the
+//     domain is empty for evaluations made without a provider.
+//   - confidence_telemetry_traces_dropped_total, the number of telemetry traces dropped before being sent.
+//   - confidence_event_uploads_total, the number of event batch uploads by outcome.
+type Metrics struct {
+	resolveRequests *prom.CounterVec
+	resolveDuration *prom.HistogramVec
+	evaluations     *prom.CounterVec
+	droppedTraces   prom.Counter
+	eventUploads    *prom.CounterVec
+}
+
+// Register creates the collectors of a Metrics and registers them with registerer. It fails if any of them can't be
+// registered, e.g. because Register was called before with the same registerer.
+func Register(registerer prom.Registerer) (*Metrics, error) {
+	metrics := &Metrics{
+		resolveRequests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "resolve_requests_total",
+			Help:      "Number of flag resolves by status, cached resolves are served without a request.",
+		}, []string{"status"}),
+		resolveDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "resolve_request_duration_seconds",
+			Help:      "Duration of the requests to the flag resolver.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"status"}),
+		evaluations: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "flag_evaluations_total",
+			Help:      "Number of flag evaluations by flag, reason and OpenFeature domain.",
+		}, []string{"flag", "reason", "domain"}),
+		droppedTraces: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "telemetry_traces_dropped_total",
+			Help:      "Number of telemetry traces dropped because too many were recorded between two resolves.",
+		}),
+		eventUploads: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "event_uploads_total",
+			Help:      "Number of event batch uploads by outcome.",
+		}, []string{"outcome"}),
+	}
+
+	for _, collector := range []prom.Collector{metrics.resolveRequests, metrics.resolveDuration, metrics.evaluations,
+		metrics.droppedTraces, metrics.eventUploads} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+// evaluationKey is the context key of the evaluation a resolve request is sent for.
+type evaluationKey struct{}
+
+// evaluation tells whether a resolve request was sent for an evaluation.
+type evaluation struct {
+	requested bool
+}
+
+func (m *Metrics) StartEvaluation(ctx context.Context, flag string) (context.Context, func(c.ResolutionDetail)) {
+	current := &evaluation{}
+	return context.WithValue(ctx, evaluationKey{}, current), func(detail c.ResolutionDetail) {
+		domain, _ := detail.FlagMetadata[c.FlagMetadataDomain].(string)
+		m.evaluations.WithLabelValues(flagName(flag, detail), strings.ToLower(string(detail.Reason)), domain).Inc()
+		// Flags served stale after a failed request are counted by the status of the request.
+		source, _ := detail.FlagMetadata[c.FlagMetadataSource].(string)
+		if source == string(c.ResolveSourceCache) && !current.requested {
+			m.resolveRequests.WithLabelValues(StatusCached).Inc()
+		}
+	}
+}
+
+func (m *Metrics) StartResolveRequest(ctx context.Context,
+	_ c.ResolveRequest) (context.Context, func(c.ResolveResponse, error)) {
+	if current, ok := ctx.Value(evaluationKey{}).(*evaluation); ok {
+		current.requested = true
+	}
+	startTime := time.Now()
+	return ctx, func(_ c.ResolveResponse, err error) {
+		status := c.ResolveStatus(err)
+		m.resolveRequests.WithLabelValues(status).Inc()
+		m.resolveDuration.WithLabelValues(status).Observe(time.Since(startTime).Seconds())
+	}
+}
+
+func (m *Metrics) StartEventUpload(ctx context.Context, _ c.EventBatchRequest) (context.Context, func(error)) {
+	return ctx, func(err error) {
+		outcome := OutcomeSuccess
+		if err != nil {
+			outcome = OutcomeError
+		}
+		m.eventUploads.WithLabelValues(outcome).Inc()
+	}
+}
+
+func (m *Metrics) TelemetryTraceDropped() {
+	m.droppedTraces.Inc()
+}
+
+// flagName returns the name of the evaluated flag, without the property path, to keep the cardinality of the flag
+// label low.
+func flagName(flag string, detail c.ResolutionDetail) string {
+	if name, ok := detail.FlagMetadata[c.FlagMetadataFlag].(string); ok {
+		return strings.TrimPrefix(name, "flags/")
+	}
+	name, _, _ := strings.Cut(flag, ".")
+	return name
+}
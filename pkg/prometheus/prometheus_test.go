@@ -0,0 +1,143 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticResolveClient struct {
+	response c.ResolveResponse
+	err      error
+}
+
+func (r staticResolveClient) SendResolveRequest(_ context.Context, _ c.ResolveRequest) (c.ResolveResponse, error) {
+	return r.response, r.err
+}
+
+func flagResponse(t *testing.T) c.ResolveResponse {
+	var response c.ResolveResponse
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"resolvedFlags": [{
+			"flag": "flags/test-flag",
+			"variant": "flags/test-flag/variants/treatment",
+			"value": {"enabled": true},
+			"flagSchema": {"schema": {"enabled": {"boolSchema": {}}}},
+			"reason": "RESOLVE_REASON_MATCH"
+		}],
+		"resolveToken": ""
+	}`), &response))
+	return response
+}
+
+func TestEvaluationsAndResolvesAreCounted(t *testing.T) {
+	metrics, err := Register(prom.NewRegistry())
+	assert.NoError(t, err)
+	config := c.APIConfig{APIKey: "apiKey", CacheTTL: time.Minute}
+	confidence := c.NewConfidenceBuilder().SetAPIConfig(config).
+		SetResolveClient(staticResolveClient{response: flagResponse(t)}).AddInstrumentation(metrics).Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.enabled", false)
+	confidence.GetBoolFlag(context.Background(), "test-flag.enabled", false)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.resolveRequests.WithLabelValues(StatusSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.resolveRequests.WithLabelValues(StatusCached)))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.resolveDuration))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.evaluations.WithLabelValues("test-flag", "targeting_match", "")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.evaluations.WithLabelValues("test-flag", "cached", "")))
+}
+
+func TestEvaluationsAreCountedByDomain(t *testing.T) {
+	metrics, err := Register(prom.NewRegistry())
+	assert.NoError(t, err)
+	confidence := c.NewConfidenceBuilder().SetAPIConfig(c.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(staticResolveClient{response: flagResponse(t)}).AddInstrumentation(metrics).Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.WithSdkInfo(c.SdkInfo{Id: "SDK_ID_GO_PROVIDER", Domain: "search"}).
+		GetBoolFlag(context.Background(), "test-flag.enabled", false)
+
+	assert.Equal(t, 1.0,
+		testutil.ToFloat64(metrics.evaluations.WithLabelValues("test-flag", "targeting_match", "search")))
+}
+
+// failingResolveClient resolves the first request and fails the following ones.
+type failingResolveClient struct {
+	response c.ResolveResponse
+	calls    *int
+}
+
+func (r failingResolveClient) SendResolveRequest(_ context.Context, _ c.ResolveRequest) (c.ResolveResponse, error) {
+	*r.calls++
+	if *r.calls > 1 {
+		return c.ResolveResponse{}, errors.New("unavailable")
+	}
+	return r.response, nil
+}
+
+func TestStaleServesAreCountedOnce(t *testing.T) {
+	metrics, err := Register(prom.NewRegistry())
+	assert.NoError(t, err)
+	calls := 0
+	config := c.APIConfig{APIKey: "apiKey", CacheTTL: time.Nanosecond}
+	confidence := c.NewConfidenceBuilder().SetAPIConfig(config).
+		SetResolveClient(failingResolveClient{response: flagResponse(t), calls: &calls}).
+		AddInstrumentation(metrics).Build()
+	confidence.PutContext("targeting_key", "user1")
+
+	confidence.GetBoolFlag(context.Background(), "test-flag.enabled", false)
+	time.Sleep(time.Millisecond)
+	detail := confidence.GetBoolFlag(context.Background(), "test-flag.enabled", false)
+
+	assert.Equal(t, c.CachedReason, detail.Reason)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.resolveRequests.WithLabelValues(StatusSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.resolveRequests.WithLabelValues(StatusError)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.resolveRequests.WithLabelValues(StatusCached)))
+}
+
+func TestFailedResolvesAreCountedByStatus(t *testing.T) {
+	metrics, err := Register(prom.NewRegistry())
+	assert.NoError(t, err)
+
+	_, done := metrics.StartResolveRequest(context.Background(), c.ResolveRequest{})
+	done(c.ResolveResponse{}, context.DeadlineExceeded)
+	_, done = metrics.StartResolveRequest(context.Background(), c.ResolveRequest{})
+	done(c.ResolveResponse{}, errors.New("unavailable"))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.resolveRequests.WithLabelValues(StatusTimeout)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.resolveRequests.WithLabelValues(StatusError)))
+}
+
+func TestEventUploadsAndDroppedTracesAreCounted(t *testing.T) {
+	metrics, err := Register(prom.NewRegistry())
+	assert.NoError(t, err)
+
+	_, done := metrics.StartEventUpload(context.Background(), c.EventBatchRequest{})
+	done(nil)
+	_, done = metrics.StartEventUpload(context.Background(), c.EventBatchRequest{})
+	done(errors.New("unavailable"))
+	metrics.TelemetryTraceDropped()
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.eventUploads.WithLabelValues(OutcomeSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.eventUploads.WithLabelValues(OutcomeError)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.droppedTraces))
+}
+
+func TestRegisterFailsWhenAlreadyRegistered(t *testing.T) {
+	registry := prom.NewRegistry()
+	_, err := Register(registry)
+	assert.NoError(t, err)
+
+	_, err = Register(registry)
+
+	var alreadyRegistered prom.AlreadyRegisteredError
+	assert.ErrorAs(t, err, &alreadyRegistered)
+}
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// confidenceTargetingKey is the Confidence context field the OpenFeature targeting key is mapped to.
+const confidenceTargetingKey = "targeting_key"
+
+// contextMapping converts OpenFeature evaluation contexts to Confidence contexts.
+type contextMapping struct {
+	attributes     map[string]string
+	nestDottedKeys bool
+}
+
+func newContextMapping(options ProviderOptions) contextMapping {
+	attributes := make(map[string]string, len(options.AttributeMapping))
+	for from, to := range options.AttributeMapping {
+		attributes[from] = to
+	}
+	return contextMapping{attributes: attributes, nestDottedKeys: options.NestDottedKeys}
+}
+
+// toConfidenceContext builds a new Confidence context from evalCtx, which is never modified. The targeting key is
+// mapped to "targeting_key" unless AttributeMapping says otherwise.
+func (m contextMapping) toConfidenceContext(evalCtx openfeature.FlattenedContext) map[string]interface{} {
+	fields := make([]mappedField, 0, len(evalCtx))
+	for key := range evalCtx {
+		fields = append(fields, m.field(key))
+	}
+	// Sorting by target makes conflicts deterministic: a nested field replaces a plain value of its parent key, since
+	// the parent sorts first, and of two attributes mapped to the same field the one with the greater key wins.
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].target != fields[j].target {
+			return fields[i].target < fields[j].target
+		}
+		return fields[i].key < fields[j].key
+	})
+
+	context := make(map[string]interface{}, len(evalCtx))
+	for _, field := range fields {
+		if field.nested {
+			setNested(context, strings.Split(field.target, "."), evalCtx[field.key])
+		} else {
+			context[field.target] = evalCtx[field.key]
+		}
+	}
+	return context
+}
+
+// mappedField is an attribute of the evaluation context and the Confidence context field it is mapped to.
+type mappedField struct {
+	key    string
+	target string
+	// nested is set when dots in target nest the value into structs.
+	nested bool
+}
+
+func (m contextMapping) field(key string) mappedField {
+	if target, mapped := m.attributes[key]; mapped {
+		return mappedField{key: key, target: target, nested: true}
+	}
+	target := key
+	if key == openfeature.TargetingKey {
+		target = confidenceTargetingKey
+	}
+	return mappedField{key: key, target: target, nested: m.nestDottedKeys}
+}
+
+// setNested sets the field at path in context, creating the structs along the path. Structs along the path are
+// copied before they are written to, so values taken from the evaluation context are never modified.
+func setNested(context map[string]interface{}, path []string, value interface{}) {
+	if len(path) == 1 {
+		context[path[0]] = value
+		return
+	}
+
+	nested := map[string]interface{}{}
+	if existing, ok := context[path[0]].(map[string]interface{}); ok {
+		for key, existingValue := range existing {
+			nested[key] = existingValue
+		}
+	}
+	setNested(nested, path[1:], value)
+	context[path[0]] = nested
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	confidence "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextMappingDoesNotMutateInput(t *testing.T) {
+	user := map[string]interface{}{"id": "u1"}
+	evalCtx := openfeature.FlattenedContext{"targetingKey": "user1", "user": user, "user.country": "SE"}
+	mapping := newContextMapping(ProviderOptions{NestDottedKeys: true})
+
+	converted := mapping.toConfidenceContext(evalCtx)
+
+	assert.Equal(t, map[string]interface{}{
+		"targeting_key": "user1",
+		"user":          map[string]interface{}{"id": "u1", "country": "SE"},
+	}, converted)
+	assert.Equal(t, openfeature.FlattenedContext{"targetingKey": "user1", "user": user, "user.country": "SE"}, evalCtx)
+	assert.Equal(t, map[string]interface{}{"id": "u1"}, user)
+}
+
+func TestContextMappingRenamesAttributes(t *testing.T) {
+	mapping := newContextMapping(ProviderOptions{AttributeMapping: map[string]string{
+		"targetingKey": "user_id",
+		"country":      "geo.country",
+	}})
+
+	converted := mapping.toConfidenceContext(openfeature.FlattenedContext{
+		"targetingKey": "user1",
+		"country":      "SE",
+		"plan.tier":    "premium",
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"user_id":   "user1",
+		"geo":       map[string]interface{}{"country": "SE"},
+		"plan.tier": "premium",
+	}, converted)
+}
+
+func TestContextMappingResolvesCollisionsByTarget(t *testing.T) {
+	// "a" sorts before "profile" as a source key, but is mapped to the nested field and still wins over the plain
+	// value mapped to its parent.
+	mapping := newContextMapping(ProviderOptions{AttributeMapping: map[string]string{
+		"a":       "user.country",
+		"profile": "user",
+		"region":  "geo",
+		"zone":    "geo",
+	}})
+
+	for i := 0; i < 10; i++ {
+		converted := mapping.toConfidenceContext(openfeature.FlattenedContext{
+			"a":       "SE",
+			"profile": "premium",
+			"region":  "eu",
+			"zone":    "north",
+		})
+
+		assert.Equal(t, map[string]interface{}{
+			"user": map[string]interface{}{"country": "SE"},
+			"geo":  "north",
+		}, converted)
+	}
+}
+
+func TestConcurrentEvaluationsShareContext(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	evalCtx := openfeature.NewEvaluationContext("user1", map[string]interface{}{"country": "SE"})
+	provider := NewFlagProvider(confidence.NewConfidenceBuilder().
+		SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(MockResolveClient{MockedResponse: templateResponse(), TestingT: t}).
+		Build())
+	flattened := flattenContext(evalCtx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.BooleanValue(context.Background(), "test-flag.boolean-key", false, evalCtx)
+			provider.BooleanEvaluation(context.Background(), "test-flag.boolean-key", false, flattened)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, openfeature.FlattenedContext{"targetingKey": "user1", "country": "SE"}, flattened)
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+)
+
+// SetNamedProviders creates a provider for each Confidence in confidences and binds it with
+// openfeature.SetNamedProvider to the domain it is keyed by. options apply to every provider, with Domain set to the
+// domain of the provider. It stops at the first registration that fails and returns the providers registered so far.
+func SetNamedProviders(confidences map[string]c.Confidence, options ProviderOptions) (map[string]*FlagProvider, error) {
+	domains := make([]string, 0, len(confidences))
+	for domain := range confidences {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	providers := make(map[string]*FlagProvider, len(confidences))
+	for _, domain := range domains {
+		domainOptions := options
+		domainOptions.Domain = domain
+		provider := NewFlagProviderWithOptions(confidences[domain], domainOptions)
+		if err := openfeature.SetNamedProvider(domain, provider); err != nil {
+			return providers, fmt.Errorf("failed to register the provider of domain %s: %w", domain, err)
+		}
+		providers[domain] = provider
+	}
+	return providers, nil
+}
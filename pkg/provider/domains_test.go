@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	confidence "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+)
+
+type secretResolveClient struct {
+	secret   string
+	variant  string
+	TestingT *testing.T
+}
+
+func (r secretResolveClient) SendResolveRequest(_ context.Context,
+	request confidence.ResolveRequest) (confidence.ResolveResponse, error) {
+	assert.Equal(r.TestingT, r.secret, request.ClientSecret)
+	response := templateResponse()
+	response.ResolvedFlags[0].Variant = r.variant
+	return response, nil
+}
+
+func TestSetNamedProvidersBindsEachDomain(t *testing.T) {
+	confidences := map[string]confidence.Confidence{}
+	for _, domain := range []string{"domains-search", "domains-ads"} {
+		confidences[domain] = confidence.NewConfidenceBuilder().
+			SetAPIConfig(confidence.APIConfig{APIKey: domain + "-secret"}).
+			SetResolveClient(secretResolveClient{secret: domain + "-secret", variant: domain, TestingT: t}).
+			Build()
+	}
+
+	providers, err := SetNamedProviders(confidences, ProviderOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, providers, 2)
+	for domain, provider := range providers {
+		assert.Equal(t, "ConfidenceFlagProvider/"+domain, provider.Metadata().Name)
+		client := openfeature.NewClient(domain)
+		assert.Eventually(t, func() bool { return client.State() == openfeature.ReadyState },
+			time.Second, 10*time.Millisecond)
+
+		details, err := client.StringValueDetails(context.Background(), "test-flag.string-key", "default",
+			openfeature.NewEvaluationContext("user1", map[string]interface{}{}))
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain, details.Variant)
+		assert.Equal(t, domain, details.FlagMetadata[confidence.FlagMetadataDomain])
+	}
+}
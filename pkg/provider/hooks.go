@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+)
+
+// LoggingHook logs evaluations, successful ones at debug level and failed ones at warn level.
+type LoggingHook struct {
+	openfeature.UnimplementedHook
+	logger *slog.Logger
+}
+
+// NewLoggingHook returns a LoggingHook writing to logger, or to the default logger if logger is nil.
+func NewLoggingHook(logger *slog.Logger) LoggingHook {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return LoggingHook{logger: logger}
+}
+
+func (h LoggingHook) After(_ context.Context, hookContext openfeature.HookContext,
+	details openfeature.InterfaceEvaluationDetails, _ openfeature.HookHints) error {
+	h.logger.Debug("Flag evaluated", c.LogKeyFlag, hookContext.FlagKey(), c.LogKeyVariant, details.Variant,
+		c.LogKeyReason, details.Reason)
+	return nil
+}
+
+func (h LoggingHook) Error(_ context.Context, hookContext openfeature.HookContext, err error,
+	_ openfeature.HookHints) {
+	h.logger.Warn("Flag evaluation failed", c.LogKeyFlag, hookContext.FlagKey(), c.LogKeyError, err)
+}
+
+// TargetingKeyHook rejects evaluations whose context has no targeting key with TargetingKeyMissingCode.
+type TargetingKeyHook struct {
+	openfeature.UnimplementedHook
+}
+
+func NewTargetingKeyHook() TargetingKeyHook {
+	return TargetingKeyHook{}
+}
+
+func (h TargetingKeyHook) Before(_ context.Context, hookContext openfeature.HookContext,
+	_ openfeature.HookHints) (*openfeature.EvaluationContext, error) {
+	if hookContext.EvaluationContext().TargetingKey() == "" {
+		return nil, openfeature.NewTargetingKeyMissingResolutionError("the evaluation context has no targeting key")
+	}
+	return nil, nil
+}
+
+// TelemetryHook records evaluations that fail with TYPE_MISMATCH as PROTO_TRACE_ID_FLAG_TYPE_MISMATCH telemetry traces
+// of a Confidence, whether the mismatch comes from the provider or from another hook. Enable it through
+// ProviderOptions.EnableTelemetryHook, so that the provider leaves the mismatches of its evaluations to the hook.
+type TelemetryHook struct {
+	openfeature.UnimplementedHook
+	confidence c.Confidence
+}
+
+func NewTelemetryHook(confidence c.Confidence) TelemetryHook {
+	return TelemetryHook{confidence: confidence}
+}
+
+func (h TelemetryHook) Error(_ context.Context, _ openfeature.HookContext, err error, _ openfeature.HookHints) {
+	if isTypeMismatch(err) {
+		h.confidence.RecordTypeMismatch()
+	}
+}
+
+// isTypeMismatch reports whether err, or an error it wraps, is a TYPE_MISMATCH resolution error. OpenFeature only
+// exposes the code of resolution errors in their message, "TYPE_MISMATCH: <message>", and passes the errors of
+// providers on as plain errors with that message.
+func isTypeMismatch(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if strings.HasPrefix(err.Error(), string(openfeature.TypeMismatchCode)+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	confidence "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetingKeyHookRejectsMissingTargetingKey(t *testing.T) {
+	resolveClient := MockResolveClient{MockedResponse: templateResponse(), TestingT: t}
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(resolveClient).Build()
+	provider := NewFlagProviderWithOptions(conf, ProviderOptions{RequireTargetingKey: true})
+	assert.NoError(t, openfeature.SetNamedProviderAndWait("hooks-targeting-key", provider))
+	client := openfeature.NewClient("hooks-targeting-key")
+
+	missing, err := client.BooleanValue(context.Background(), "test-flag.boolean-key", true,
+		openfeature.NewEvaluationContext("", map[string]interface{}{}))
+	assert.Equal(t, true, missing)
+	var resolutionError openfeature.ResolutionError
+	assert.True(t, errors.As(err, &resolutionError))
+	assert.Contains(t, err.Error(), string(openfeature.TargetingKeyMissingCode))
+
+	present, err := client.BooleanValue(context.Background(), "test-flag.boolean-key", false,
+		openfeature.NewEvaluationContext("user1", map[string]interface{}{}))
+	assert.NoError(t, err)
+	assert.Equal(t, true, present)
+}
+
+func TestLoggingHookLogsEvaluations(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	resolveClient := MockResolveClient{MockedResponse: templateResponse(), TestingT: t}
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(resolveClient).SetLogger(logger).Build()
+	provider := NewFlagProviderWithOptions(conf, ProviderOptions{EnableLoggingHook: true})
+	assert.NoError(t, openfeature.SetNamedProviderAndWait("hooks-logging", provider))
+	client := openfeature.NewClient("hooks-logging")
+	evalCtx := openfeature.NewEvaluationContext("user1", map[string]interface{}{})
+
+	client.BooleanValue(context.Background(), "test-flag.boolean-key", true, evalCtx)
+	client.StringValue(context.Background(), "test-flag.boolean-key", "default", evalCtx)
+
+	assert.Contains(t, logs.String(), `msg="Flag evaluated" flag=test-flag.boolean-key`)
+	assert.Contains(t, logs.String(), `msg="Flag evaluation failed" flag=test-flag.boolean-key`)
+}
+
+func TestProviderRecordsTypeMismatchesOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(templateResponse())
+	}))
+	defer server.Close()
+
+	for _, enableTelemetryHook := range []bool{false, true} {
+		config := confidence.APIConfig{APIKey: "apiKey", APIResolveBaseUrl: server.URL}
+		resolveClient := confidence.NewHttpResolveClient(config)
+		conf := confidence.NewConfidenceBuilder().SetAPIConfig(config).SetResolveClient(resolveClient).Build()
+		provider := NewFlagProviderWithOptions(conf, ProviderOptions{EnableTelemetryHook: enableTelemetryHook})
+		domain := fmt.Sprintf("hooks-telemetry-%t", enableTelemetryHook)
+		assert.NoError(t, openfeature.SetNamedProviderAndWait(domain, provider))
+		client := openfeature.NewClient(domain)
+		evalCtx := openfeature.NewEvaluationContext("user1", map[string]interface{}{})
+
+		client.BooleanValue(context.Background(), "test-flag.boolean-key", true, evalCtx)
+		client.StringValue(context.Background(), "test-flag.boolean-key", "default", evalCtx)
+
+		traces := resolveClient.PullTraces()
+		assert.Equal(t, 1, countTraces(traces, confidence.ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH))
+		assert.Equal(t, 0, countTraces(traces, confidence.ProtoLibraryTraces_PROTO_TRACE_ID_WITH_CONTEXT))
+	}
+}
+
+// typeCheckingHook rejects string values that aren't upper case, like an application validating flag values.
+type typeCheckingHook struct {
+	openfeature.UnimplementedHook
+}
+
+func (typeCheckingHook) After(_ context.Context, _ openfeature.HookContext,
+	details openfeature.InterfaceEvaluationDetails, _ openfeature.HookHints) error {
+	if value, ok := details.Value.(string); ok && value != strings.ToUpper(value) {
+		return openfeature.NewTypeMismatchResolutionError("the value isn't upper case")
+	}
+	return nil
+}
+
+func TestTelemetryHookRecordsMismatchesOfOtherHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(templateResponse())
+	}))
+	defer server.Close()
+
+	config := confidence.APIConfig{APIKey: "apiKey", APIResolveBaseUrl: server.URL}
+	resolveClient := confidence.NewHttpResolveClient(config)
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(config).SetResolveClient(resolveClient).Build()
+	provider := NewFlagProviderWithOptions(conf, ProviderOptions{EnableTelemetryHook: true})
+	assert.NoError(t, openfeature.SetNamedProviderAndWait("hooks-telemetry-other", provider))
+	client := openfeature.NewClient("hooks-telemetry-other")
+	client.AddHooks(typeCheckingHook{})
+	evalCtx := openfeature.NewEvaluationContext("user1", map[string]interface{}{})
+
+	client.BooleanValue(context.Background(), "test-flag.boolean-key", false, evalCtx)
+	client.StringValue(context.Background(), "test-flag.string-key", "default", evalCtx)
+
+	assert.Equal(t, 1,
+		countTraces(resolveClient.PullTraces(), confidence.ProtoLibraryTraces_PROTO_TRACE_ID_FLAG_TYPE_MISMATCH))
+}
+
+func countTraces(traces []*confidence.ProtoLibraryTraces_ProtoTrace,
+	id confidence.ProtoLibraryTraces_ProtoTraceId) int {
+	var count int
+	for _, trace := range traces {
+		if trace.Id == id {
+			count++
+		}
+	}
+	return count
+}
+
+func TestProviderHasNoHooksByDefault(t *testing.T) {
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).Build()
+
+	assert.Empty(t, NewFlagProvider(conf).Hooks())
+	assert.Len(t, NewFlagProviderWithOptions(conf, ProviderOptions{
+		EnableLoggingHook:   true,
+		RequireTargetingKey: true,
+		EnableTelemetryHook: true,
+	}).Hooks(), 3)
+}
@@ -2,30 +2,258 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"github.com/open-feature/go-sdk/openfeature"
 	c "github.com/spotify/confidence-sdk-go/pkg/confidence"
+	"log/slog"
 	"reflect"
+	"sync"
+	"time"
 )
 
+// defaultShutdownTimeout bounds how long Shutdown waits for pending events when no EventTimeout is configured.
+const defaultShutdownTimeout = 10 * time.Second
+
+// defaultInitTimeout bounds how long Init waits for the initial resolve when no ResolveTimeout is configured.
+const defaultInitTimeout = 10 * time.Second
+
+const providerName = "ConfidenceFlagProvider"
+
+// sdkInfo attributes the resolves, events and telemetry of a provider bound to domain to OpenFeature.
+func sdkInfo(domain string) c.SdkInfo {
+	return c.SdkInfo{
+		Id:      "SDK_ID_GO_PROVIDER",
+		Version: c.SDK_VERSION,
+		Library: c.ProtoLibraryTraces_PROTO_LIBRARY_OPEN_FEATURE,
+		Domain:  domain,
+	}
+}
+
+// eventBufferSize bounds the number of provider events waiting to be picked up by OpenFeature.
+const eventBufferSize = 16
+
 type FlagProvider struct {
 	confidence c.Confidence
+	status     *providerStatus
+	events     chan openfeature.Event
+	hooks      []openfeature.Hook
+	// contextMapping converts the OpenFeature evaluation context, the zero value only maps the targeting key.
+	contextMapping contextMapping
+	domain         string
+	logger         *slog.Logger
+	// telemetryHook is set when type mismatches are recorded by a TelemetryHook instead of the provider.
+	telemetryHook bool
+	// unsubscribe removes the status listener of the provider from the Confidence, it is nil for a zero provider.
+	unsubscribe func()
+}
+
+// ProviderOptions selects the hooks shipped with the provider, all of them are disabled by default.
+type ProviderOptions struct {
+	// Domain is the OpenFeature domain the provider is bound to. It is included in the provider name and in the log
+	// records of the provider, so that providers of different domains can be told apart.
+	Domain string
+	// EnableLoggingHook logs every evaluation with the logger of the Confidence, see LoggingHook.
+	EnableLoggingHook bool
+	// RequireTargetingKey rejects evaluations without a targeting key, see TargetingKeyHook.
+	RequireTargetingKey bool
+	// EnableTelemetryHook records type mismatched evaluations with a TelemetryHook, which also sees the mismatches
+	// raised by other hooks. The provider records the mismatches of its own evaluations otherwise.
+	EnableTelemetryHook bool
+	// AttributeMapping renames OpenFeature context attributes to Confidence context fields. A dotted field name
+	// nests the value into structs, e.g. "country" mapped to "user.country". The targeting key attribute is
+	// "targetingKey", it is mapped to "targeting_key" by default.
+	AttributeMapping map[string]string
+	// NestDottedKeys nests unmapped attributes with dotted keys into structs, so that "user.country" becomes the
+	// field country of the struct user.
+	NestDottedKeys bool
+}
+
+// providerStatus holds the OpenFeature state of a FlagProvider, it is shared by all copies of the provider.
+type providerStatus struct {
+	mu    sync.RWMutex
+	state openfeature.State
+	// initializing is set while Init runs, OpenFeature reports the readiness of the provider once Init returns.
+	initializing bool
+}
+
+func (s *providerStatus) get() openfeature.State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+func (s *providerStatus) set(state openfeature.State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+}
+
+func (s *providerStatus) isInitializing() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.initializing
+}
+
+func (s *providerStatus) setInitializing(initializing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.initializing = initializing
 }
 
 func NewFlagProvider(confidence c.Confidence) *FlagProvider {
-	return &FlagProvider{
-		confidence: confidence,
+	return NewFlagProviderWithOptions(confidence, ProviderOptions{})
+}
+
+func NewFlagProviderWithOptions(confidence c.Confidence, options ProviderOptions) *FlagProvider {
+	confidence = confidence.WithSdkInfo(sdkInfo(options.Domain))
+	provider := &FlagProvider{
+		confidence:     confidence,
+		status:         &providerStatus{state: openfeature.NotReadyState},
+		events:         make(chan openfeature.Event, eventBufferSize),
+		hooks:          []openfeature.Hook{},
+		contextMapping: newContextMapping(options),
+		domain:         options.Domain,
+		logger:         confidence.GetLogger(),
+		telemetryHook:  options.EnableTelemetryHook,
+	}
+	if options.Domain != "" {
+		provider.logger = provider.logger.With(c.LogKeyDomain, options.Domain)
+	}
+	if options.RequireTargetingKey {
+		provider.hooks = append(provider.hooks, NewTargetingKeyHook())
 	}
+	if options.EnableLoggingHook {
+		provider.hooks = append(provider.hooks, NewLoggingHook(provider.logger))
+	}
+	if options.EnableTelemetryHook {
+		provider.hooks = append(provider.hooks, NewTelemetryHook(confidence))
+	}
+	provider.unsubscribe = confidence.OnStatusEvent(provider.handleStatusEvent)
+	return provider
+}
+
+// EventChannel returns the channel OpenFeature receives the provider events from.
+func (e FlagProvider) EventChannel() <-chan openfeature.Event {
+	return e.events
+}
+
+// handleStatusEvent updates the provider state and emits the matching OpenFeature event.
+func (e *FlagProvider) handleStatusEvent(event c.StatusEvent) {
+	var eventType openfeature.EventType
+	switch event.Type {
+	case c.StatusReady:
+		if e.status.isInitializing() {
+			return
+		}
+		eventType = openfeature.ProviderReady
+		e.status.set(openfeature.ReadyState)
+	case c.StatusError:
+		eventType = openfeature.ProviderError
+		e.status.set(openfeature.ErrorState)
+	case c.StatusStale:
+		eventType = openfeature.ProviderStale
+		e.status.set(openfeature.StaleState)
+	case c.StatusConfigurationChanged:
+		eventType = openfeature.ProviderConfigChange
+	default:
+		return
+	}
+
+	select {
+	case e.events <- openfeature.Event{
+		ProviderName: e.Metadata().Name,
+		EventType:    eventType,
+		ProviderEventDetails: openfeature.ProviderEventDetails{
+			Message:     event.Message,
+			FlagChanges: event.ChangedFlags,
+		},
+	}:
+	default:
+		e.log().Warn("Dropping provider event, the event buffer is full", c.LogKeyEvent, eventType)
+	}
+}
+
+// The lifecycle methods use pointer receivers, OpenFeature compares registered state handlers and a FlagProvider
+// value is not comparable. Register the provider returned by NewFlagProvider to make use of them.
+
+// Init validates the configuration of the underlying Confidence and warms it up with an initial resolve for
+// evaluationContext, see Confidence.WarmUp. The provider is ready once the resolve succeeds, Init returns its error
+// otherwise.
+func (e *FlagProvider) Init(evaluationContext openfeature.EvaluationContext) error {
+	if e.status == nil {
+		e.status = &providerStatus{}
+	}
+	if err := e.confidence.GetConfig().Validate(); err != nil {
+		e.status.set(openfeature.ErrorState)
+		return err
+	}
+
+	timeout := e.confidence.GetConfig().ResolveTimeout
+	if timeout <= 0 {
+		timeout = defaultInitTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	e.status.setInitializing(true)
+	defer e.status.setInitializing(false)
+	confidenceContext := e.contextMapping.toConfidenceContext(flattenContext(evaluationContext))
+	if err := e.confidence.WithEvaluationContext(confidenceContext).WarmUp(ctx); err != nil {
+		e.status.set(openfeature.ErrorState)
+		return fmt.Errorf("initial resolve failed: %w", err)
+	}
+	e.status.set(openfeature.ReadyState)
+	return nil
+}
+
+// Shutdown flushes pending events, closes the underlying Confidence and stops listening to its status.
+func (e *FlagProvider) Shutdown() {
+	if e.unsubscribe != nil {
+		e.unsubscribe()
+	}
+	timeout := e.confidence.GetConfig().EventTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := e.confidence.Close(ctx); err != nil {
+		e.log().Warn("Failed to flush events on shutdown", c.LogKeyError, err)
+	}
+	if e.status != nil {
+		e.status.set(openfeature.NotReadyState)
+	}
+}
+
+// Status returns the OpenFeature state of the provider.
+func (e *FlagProvider) Status() openfeature.State {
+	if e.status == nil {
+		return openfeature.NotReadyState
+	}
+	return e.status.get()
 }
 
 func (e FlagProvider) Metadata() openfeature.Metadata {
-	return openfeature.Metadata{Name: "ConfidenceFlagProvider"}
+	if e.domain != "" {
+		return openfeature.Metadata{Name: providerName + "/" + e.domain}
+	}
+	return openfeature.Metadata{Name: providerName}
+}
+
+// log returns the logger of the provider, falling back to the logger of the Confidence for providers that weren't
+// created with NewFlagProvider.
+func (e FlagProvider) log() *slog.Logger {
+	if e.logger != nil {
+		return e.logger
+	}
+	return e.confidence.GetLogger()
 }
 
 func (e FlagProvider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool,
 	evalCtx openfeature.FlattenedContext) openfeature.BoolResolutionDetail {
-	confidence := e.confidence.WithContext(processTargetingKey(evalCtx))
+	confidence := e.confidence.WithEvaluationContext(e.contextMapping.toConfidenceContext(evalCtx))
 	res := confidence.ResolveFlag(ctx, flag, defaultValue, reflect.Bool)
 	boolDetail := c.ToBoolResolutionDetail(res, defaultValue)
+	e.recordTypeMismatch(confidence, boolDetail.ResolutionDetail)
 	return openfeature.BoolResolutionDetail{
 		Value:                    boolDetail.Value,
 		ProviderResolutionDetail: toOFResolutionDetail(boolDetail.ResolutionDetail),
@@ -34,9 +262,10 @@ func (e FlagProvider) BooleanEvaluation(ctx context.Context, flag string, defaul
 
 func (e FlagProvider) StringEvaluation(ctx context.Context, flag string, defaultValue string,
 	evalCtx openfeature.FlattenedContext) openfeature.StringResolutionDetail {
-	confidence := e.confidence.WithContext(processTargetingKey(evalCtx))
+	confidence := e.confidence.WithEvaluationContext(e.contextMapping.toConfidenceContext(evalCtx))
 	res := confidence.ResolveFlag(ctx, flag, defaultValue, reflect.String)
 	detail := c.ToStringResolutionDetail(res, defaultValue)
+	e.recordTypeMismatch(confidence, detail.ResolutionDetail)
 	return openfeature.StringResolutionDetail{
 		Value:                    detail.Value,
 		ProviderResolutionDetail: toOFResolutionDetail(detail.ResolutionDetail),
@@ -45,9 +274,10 @@ func (e FlagProvider) StringEvaluation(ctx context.Context, flag string, default
 
 func (e FlagProvider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64,
 	evalCtx openfeature.FlattenedContext) openfeature.FloatResolutionDetail {
-	confidence := e.confidence.WithContext(processTargetingKey(evalCtx))
+	confidence := e.confidence.WithEvaluationContext(e.contextMapping.toConfidenceContext(evalCtx))
 	res := confidence.ResolveFlag(ctx, flag, defaultValue, reflect.Float64)
 	detail := c.ToFloatResolutionDetail(res, defaultValue)
+	e.recordTypeMismatch(confidence, detail.ResolutionDetail)
 	return openfeature.FloatResolutionDetail{
 		Value:                    detail.Value,
 		ProviderResolutionDetail: toOFResolutionDetail(detail.ResolutionDetail),
@@ -56,9 +286,10 @@ func (e FlagProvider) FloatEvaluation(ctx context.Context, flag string, defaultV
 
 func (e FlagProvider) IntEvaluation(ctx context.Context, flag string, defaultValue int64,
 	evalCtx openfeature.FlattenedContext) openfeature.IntResolutionDetail {
-	confidence := e.confidence.WithContext(processTargetingKey(evalCtx))
+	confidence := e.confidence.WithEvaluationContext(e.contextMapping.toConfidenceContext(evalCtx))
 	res := confidence.ResolveFlag(ctx, flag, defaultValue, reflect.Int64)
 	detail := c.ToIntResolutionDetail(res, defaultValue)
+	e.recordTypeMismatch(confidence, detail.ResolutionDetail)
 	return openfeature.IntResolutionDetail{
 		Value:                    detail.Value,
 		ProviderResolutionDetail: toOFResolutionDetail(detail.ResolutionDetail),
@@ -67,17 +298,46 @@ func (e FlagProvider) IntEvaluation(ctx context.Context, flag string, defaultVal
 
 func (e FlagProvider) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{},
 	evalCtx openfeature.FlattenedContext) openfeature.InterfaceResolutionDetail {
-	confidence := e.confidence.WithContext(processTargetingKey(evalCtx))
-	res := confidence.ResolveFlag(ctx, flag, defaultValue, reflect.Map)
+	confidence := e.confidence.WithEvaluationContext(e.contextMapping.toConfidenceContext(evalCtx))
+	res := confidence.ResolveFlag(ctx, flag, defaultValue, reflect.Interface)
 	detail := c.ToObjectResolutionDetail(res, defaultValue)
+	e.recordTypeMismatch(confidence, detail.ResolutionDetail)
 	return openfeature.InterfaceResolutionDetail{
 		Value:                    detail.Value,
 		ProviderResolutionDetail: toOFResolutionDetail(detail.ResolutionDetail),
 	}
 }
 
+// recordTypeMismatch reports detail through the telemetry of confidence if it is a type mismatch, unless a
+// TelemetryHook records it.
+func (e FlagProvider) recordTypeMismatch(confidence c.Confidence, detail c.ResolutionDetail) {
+	if detail.ErrorCode == c.TypeMismatchCode && !e.telemetryHook {
+		confidence.RecordTypeMismatch()
+	}
+}
+
+// Track sends a Confidence event named trackingEventName, with the evaluation context as the event context.
+// The event data holds the attributes of details, and the value of details as "value" unless it is zero.
+func (e FlagProvider) Track(ctx context.Context, trackingEventName string, evaluationContext openfeature.EvaluationContext,
+	details openfeature.TrackingEventDetails) {
+	confidence := e.confidence.WithEvaluationContext(e.contextMapping.toConfidenceContext(flattenContext(evaluationContext)))
+	data, valueReplaced := toTrackingData(details)
+	if valueReplaced {
+		e.log().Warn("Replacing the \"value\" tracking attribute with the tracking value", c.LogKeyEvent,
+			trackingEventName)
+	}
+	if _, exists := data["context"]; exists {
+		e.log().Warn("Dropping the reserved \"context\" tracking attribute", c.LogKeyEvent, trackingEventName)
+		delete(data, "context")
+	}
+	confidence.Track(ctx, trackingEventName, data)
+}
+
 func (e FlagProvider) Hooks() []openfeature.Hook {
-	return []openfeature.Hook{}
+	if e.hooks == nil {
+		return []openfeature.Hook{}
+	}
+	return e.hooks
 }
 
 func toOFResolutionDetail(detail c.ResolutionDetail) openfeature.ProviderResolutionDetail {
@@ -103,22 +363,44 @@ func toOFResolutionError(code c.ErrorCode, message string) openfeature.Resolutio
 		return openfeature.NewProviderNotReadyResolutionError(message)
 	case c.ParseErrorCode:
 		return openfeature.NewParseErrorResolutionError(message)
+	case c.TargetingKeyMissingCode:
+		return openfeature.NewTargetingKeyMissingResolutionError(message)
+	case c.TimeoutCode:
+		// OpenFeature has no timeout code.
+		return openfeature.NewGeneralResolutionError(fmt.Sprintf("%s: %s", c.TimeoutCode, message))
+	case "":
+		return openfeature.ResolutionError{}
 	}
-	return openfeature.ResolutionError{}
+	return openfeature.NewGeneralResolutionError(fmt.Sprintf("%s: %s", code, message))
 }
 
-func processTargetingKey(evalCtx openfeature.FlattenedContext) openfeature.FlattenedContext {
-	newEvalContext := openfeature.FlattenedContext{}
-	newEvalContext = evalCtx
-	if targetingKey, exists := evalCtx["targetingKey"]; exists {
-		newEvalContext["targeting_key"] = targetingKey
+// flattenContext converts an evaluation context to the flattened form used in evaluations.
+func flattenContext(evaluationContext openfeature.EvaluationContext) openfeature.FlattenedContext {
+	flattened := openfeature.FlattenedContext(evaluationContext.Attributes())
+	if targetingKey := evaluationContext.TargetingKey(); targetingKey != "" {
+		flattened[openfeature.TargetingKey] = targetingKey
 	}
-	delete(newEvalContext, "targetingKey")
-	return newEvalContext
+	return flattened
+}
+
+// toTrackingData returns the attributes of details with its value, which is always sent since OpenFeature can't tell
+// a zero value from a missing one. It reports whether an attribute named "value" was replaced by the value.
+func toTrackingData(details openfeature.TrackingEventDetails) (map[string]interface{}, bool) {
+	data := details.Attributes()
+	_, replaced := data["value"]
+	data["value"] = details.Value()
+	return data, replaced
 }
 
-func toOFFlagMetadata(metadata c.FlagMetadata) map[string]interface{} {
-	return metadata
+func toOFFlagMetadata(metadata c.FlagMetadata) openfeature.FlagMetadata {
+	if metadata == nil {
+		return nil
+	}
+	ofMetadata := openfeature.FlagMetadata{}
+	for key, value := range metadata {
+		ofMetadata[key] = value
+	}
+	return ofMetadata
 }
 
 func toOFReason(reason c.Reason) openfeature.Reason {
@@ -127,7 +409,15 @@ func toOFReason(reason c.Reason) openfeature.Reason {
 		return openfeature.TargetingMatchReason
 	case c.DefaultReason:
 		return openfeature.DefaultReason
-	default:
+	case c.DisabledReason:
+		return openfeature.DisabledReason
+	case c.CachedReason:
+		return openfeature.CachedReason
+	case c.StaticReason:
+		return openfeature.StaticReason
+	case c.ErrorReason:
 		return openfeature.ErrorReason
+	default:
+		return openfeature.UnknownReason
 	}
 }
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/open-feature/go-sdk/openfeature"
 	confidence "github.com/spotify/confidence-sdk-go/pkg/confidence"
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type MockResolveClient struct {
@@ -20,7 +22,10 @@ type MockResolveClient struct {
 
 func (r MockResolveClient) SendResolveRequest(_ context.Context,
 	request confidence.ResolveRequest) (confidence.ResolveResponse, error) {
-	assert.Equal(r.TestingT, "user1", request.EvaluationContext["targeting_key"])
+	// The initial resolve of Init is made for the global evaluation context, which has no targeting key.
+	if len(request.Flags) > 0 {
+		assert.Equal(r.TestingT, "user1", request.EvaluationContext["targeting_key"])
+	}
 	return r.MockedResponse, r.MockedError
 }
 
@@ -88,6 +93,55 @@ func TestResolveObjectValue(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func TestResolveListAsObjectValue(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	attributes := make(map[string]interface{})
+
+	evalDetails, _ := client.ObjectValueDetails(
+		context.Background(), "test-flag.list-key", nil, openfeature.NewEvaluationContext(
+			"user1",
+			attributes))
+
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, evalDetails.Value)
+}
+
+func TestResolveFlagMetadata(t *testing.T) {
+	client := client(t, templateResponse(), nil)
+	attributes := make(map[string]interface{})
+
+	evalDetails, _ := client.StringValueDetails(
+		context.Background(), "test-flag.string-key", "default", openfeature.NewEvaluationContext(
+			"user1",
+			attributes))
+
+	flag, _ := evalDetails.FlagMetadata.GetString(confidence.FlagMetadataFlag)
+	assert.Equal(t, "flags/test-flag", flag)
+	schemaType, _ := evalDetails.FlagMetadata.GetString(confidence.FlagMetadataSchemaType)
+	assert.Equal(t, "string", schemaType)
+	source, _ := evalDetails.FlagMetadata.GetString(confidence.FlagMetadataSource)
+	assert.Equal(t, "network", source)
+}
+
+func TestResolveArchivedFlag(t *testing.T) {
+	response := templateResponse()
+	response.ResolvedFlags[0].Reason = "RESOLVE_REASON_FLAG_ARCHIVED"
+	response.ResolvedFlags[0].Value = map[string]interface{}{}
+	client := client(t, response, nil)
+	attributes := make(map[string]interface{})
+
+	evalDetails, _ := client.BooleanValueDetails(
+		context.Background(), "test-flag.boolean-key", true, openfeature.NewEvaluationContext(
+			"user1",
+			attributes))
+
+	assert.Equal(t, true, evalDetails.Value)
+	assert.Equal(t, openfeature.DisabledReason, evalDetails.Reason)
+	assert.Equal(t, openfeature.ErrorCode(""), evalDetails.ErrorCode)
+	archived, err := evalDetails.FlagMetadata.GetBool(confidence.FlagMetadataArchived)
+	assert.NoError(t, err)
+	assert.True(t, archived)
+}
+
 func TestResolveNestedValue(t *testing.T) {
 	client := client(t, templateResponse(), nil)
 	attributes := make(map[string]interface{})
@@ -190,13 +244,252 @@ func TestResolveWithNonExistingFlag(t *testing.T) {
 	assert.Equal(t, "Flag not found", evalDetails.ErrorMessage)
 }
 
+func TestInitMarksProviderReady(t *testing.T) {
+	var requests []confidence.ResolveRequest
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(sdkRecordingResolveClient{requests: &requests}).Build()
+	provider := NewFlagProvider(conf)
+	assert.Equal(t, openfeature.NotReadyState, provider.Status())
+
+	assert.NoError(t, openfeature.SetNamedProviderAndWait("lifecycle-ready", provider))
+	assert.Equal(t, openfeature.ReadyState, provider.Status())
+	assert.Len(t, requests, 1)
+	assert.Empty(t, requests[0].Flags)
+	assert.False(t, requests[0].Apply)
+	assert.Empty(t, provider.events, "OpenFeature emits the ready event of Init itself")
+
+	provider.Shutdown()
+	assert.Equal(t, openfeature.NotReadyState, provider.Status())
+}
+
+func TestInitFailsWhenTheInitialResolveFails(t *testing.T) {
+	resolveClient := MockResolveClient{MockedError: errors.New("unavailable"), TestingT: t}
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(resolveClient).Build()
+	provider := NewFlagProvider(conf)
+
+	err := openfeature.SetNamedProviderAndWait("lifecycle-resolve-error", provider)
+
+	assert.ErrorContains(t, err, "unavailable")
+	assert.Equal(t, openfeature.ErrorState, provider.Status())
+}
+
+func TestInitFailsWithoutAPIKey(t *testing.T) {
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{}).Build()
+	provider := NewFlagProvider(conf)
+
+	err := openfeature.SetNamedProviderAndWait("lifecycle-error", provider)
+
+	assert.Error(t, err)
+	assert.Equal(t, openfeature.ErrorState, provider.Status())
+}
+
+func TestOpenCircuitEmitsProviderError(t *testing.T) {
+	resolveClient := MockResolveClient{MockedError: errors.New("unavailable"), TestingT: t}
+	config := confidence.APIConfig{APIKey: "apiKey", CircuitBreakerThreshold: 1, CircuitBreakerCooldown: time.Minute}
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(config).SetResolveClient(resolveClient).Build()
+	provider := NewFlagProvider(conf)
+
+	detail := provider.BooleanEvaluation(context.Background(), "test-flag.boolean-key", true,
+		openfeature.FlattenedContext{"targetingKey": "user1"})
+
+	assert.Equal(t, true, detail.Value)
+	event := <-provider.EventChannel()
+	assert.Equal(t, openfeature.ProviderError, event.EventType)
+	assert.Equal(t, "ConfidenceFlagProvider", event.ProviderName)
+	assert.Equal(t, openfeature.ErrorState, provider.Status())
+}
+
+func TestFirstResolveEmitsProviderReady(t *testing.T) {
+	resolveClient := MockResolveClient{MockedResponse: templateResponse(), TestingT: t}
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(resolveClient).Build()
+	provider := NewFlagProvider(conf)
+
+	provider.BooleanEvaluation(context.Background(), "test-flag.boolean-key", false,
+		openfeature.FlattenedContext{"targetingKey": "user1"})
+
+	event := <-provider.EventChannel()
+	assert.Equal(t, openfeature.ProviderReady, event.EventType)
+	assert.Equal(t, openfeature.ReadyState, provider.Status())
+}
+
+func TestProviderOfZeroConfidenceFailsToInit(t *testing.T) {
+	provider := NewFlagProvider(confidence.Confidence{})
+
+	assert.Error(t, provider.Init(openfeature.NewEvaluationContext("user1", nil)))
+	detail := provider.BooleanEvaluation(context.Background(), "test-flag.boolean-key", true,
+		openfeature.FlattenedContext{"targetingKey": "user1"})
+	provider.Shutdown()
+
+	assert.Equal(t, true, detail.Value)
+	assert.Equal(t, openfeature.NotReadyState, provider.Status())
+}
+
+func TestShutdownStopsListeningToTheConfidence(t *testing.T) {
+	resolveClient := MockResolveClient{MockedResponse: templateResponse(), TestingT: t}
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(resolveClient).Build()
+	provider := NewFlagProvider(conf)
+
+	provider.Shutdown()
+	conf.PutContext("targeting_key", "user1")
+	conf.GetBoolFlag(context.Background(), "test-flag.boolean-key", false)
+
+	assert.Empty(t, provider.EventChannel())
+	assert.Equal(t, openfeature.NotReadyState, provider.Status())
+}
+
+func TestChangedFlagEmitsConfigurationChanged(t *testing.T) {
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).Build()
+	provider := NewFlagProvider(conf)
+
+	provider.handleStatusEvent(confidence.StatusEvent{
+		Type:         confidence.StatusConfigurationChanged,
+		ChangedFlags: []string{"test-flag"},
+	})
+
+	event := <-provider.EventChannel()
+	assert.Equal(t, openfeature.ProviderConfigChange, event.EventType)
+	assert.Equal(t, []string{"test-flag"}, event.FlagChanges)
+}
+
+func TestTrackingContextNormalisesTargetingKey(t *testing.T) {
+	evalCtx := openfeature.NewEvaluationContext("user1", map[string]interface{}{"country": "SE"})
+
+	assert.Equal(t, map[string]interface{}{"targeting_key": "user1", "country": "SE"},
+		contextMapping{}.toConfidenceContext(flattenContext(evalCtx)))
+}
+
+func TestTrackingDetailsToEventData(t *testing.T) {
+	details := openfeature.NewTrackingEventDetails(9.99).Add("currency", "SEK")
+
+	data, replaced := toTrackingData(details)
+	assert.Equal(t, map[string]interface{}{"currency": "SEK", "value": 9.99}, data)
+	assert.False(t, replaced)
+	data, _ = toTrackingData(details.Copy(0))
+	assert.Equal(t, map[string]interface{}{"currency": "SEK", "value": 0.0}, data)
+}
+
+func TestTrackingValueReplacesTheValueAttribute(t *testing.T) {
+	details := openfeature.NewTrackingEventDetails(9.99).Add("value", "high")
+
+	data, replaced := toTrackingData(details)
+
+	assert.Equal(t, map[string]interface{}{"value": 9.99}, data)
+	assert.True(t, replaced)
+}
+
+func TestProviderImplementsOpenFeatureInterfaces(t *testing.T) {
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).Build()
+	provider := NewFlagProvider(conf)
+
+	assert.Implements(t, (*openfeature.Tracker)(nil), provider)
+	assert.Implements(t, (*openfeature.StateHandler)(nil), provider)
+	assert.Implements(t, (*openfeature.EventHandler)(nil), provider)
+}
+
+func TestErrorCodesRoundTrip(t *testing.T) {
+	tests := []struct {
+		code          confidence.ErrorCode
+		expectCode    openfeature.ErrorCode
+		expectMessage string
+	}{
+		{confidence.ProviderNotReadyCode, openfeature.ProviderNotReadyCode, "message"},
+		{confidence.FlagNotFoundCode, openfeature.FlagNotFoundCode, "message"},
+		{confidence.ParseErrorCode, openfeature.ParseErrorCode, "message"},
+		{confidence.TypeMismatchCode, openfeature.TypeMismatchCode, "message"},
+		{confidence.TargetingKeyMissingCode, openfeature.TargetingKeyMissingCode, "message"},
+		{confidence.InvalidContextCode, openfeature.InvalidContextCode, "message"},
+		{confidence.TimeoutCode, openfeature.GeneralCode, "TIMEOUT: message"},
+		{confidence.GeneralCode, openfeature.GeneralCode, "message"},
+		{confidence.ErrorCode("NEW_CODE"), openfeature.GeneralCode, "NEW_CODE: message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			detail := toOFResolutionDetail(confidence.ResolutionDetail{
+				Reason:       confidence.ErrorReason,
+				ErrorCode:    tt.code,
+				ErrorMessage: "message",
+			})
+
+			assert.Error(t, detail.Error())
+			assert.Equal(t, tt.expectCode, detail.ResolutionDetail().ErrorCode)
+			assert.Equal(t, tt.expectMessage, detail.ResolutionDetail().ErrorMessage)
+			assert.Equal(t, openfeature.ErrorReason, detail.Reason)
+		})
+	}
+
+	assert.NoError(t, toOFResolutionDetail(confidence.ResolutionDetail{Reason: confidence.DefaultReason}).Error())
+}
+
+func TestReasonsRoundTrip(t *testing.T) {
+	tests := map[confidence.Reason]openfeature.Reason{
+		confidence.TargetingMatchReason: openfeature.TargetingMatchReason,
+		confidence.DefaultReason:        openfeature.DefaultReason,
+		confidence.DisabledReason:       openfeature.DisabledReason,
+		confidence.CachedReason:         openfeature.CachedReason,
+		confidence.StaticReason:         openfeature.StaticReason,
+		confidence.ErrorReason:          openfeature.ErrorReason,
+		confidence.UnknownReason:        openfeature.UnknownReason,
+		confidence.Reason("NEW_REASON"): openfeature.UnknownReason,
+	}
+
+	for reason, expected := range tests {
+		assert.Equal(t, expected, toOFReason(reason), "reason %s", reason)
+	}
+}
+
+type sdkRecordingResolveClient struct {
+	requests *[]confidence.ResolveRequest
+}
+
+func (r sdkRecordingResolveClient) SendResolveRequest(_ context.Context,
+	request confidence.ResolveRequest) (confidence.ResolveResponse, error) {
+	*r.requests = append(*r.requests, request)
+	return templateResponse(), nil
+}
+
+func TestProviderResolvesAsOpenFeatureSdk(t *testing.T) {
+	var requests []confidence.ResolveRequest
+	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).
+		SetResolveClient(sdkRecordingResolveClient{requests: &requests}).Build()
+	provider := NewFlagProvider(conf)
+
+	provider.BooleanEvaluation(context.Background(), "test-flag.boolean-key", false, openfeature.FlattenedContext{})
+	conf.GetBoolValue(context.Background(), "test-flag.boolean-key", false)
+
+	sdks, _ := json.Marshal([]interface{}{requests[0].Sdk, requests[1].Sdk})
+	assert.JSONEq(t, fmt.Sprintf(`[{"id": "SDK_ID_GO_PROVIDER", "version": %[1]q}, {"id": "SDK_ID_GO_CONFIDENCE", "version": %[1]q}]`,
+		confidence.SDK_VERSION), string(sdks))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestResolveTimeoutIsReportedAsError(t *testing.T) {
+	client := client(t, templateResponse(), timeoutError{})
+
+	evalDetails, err := client.BooleanValueDetails(
+		context.Background(), "test-flag.boolean-key", true, openfeature.NewEvaluationContext(
+			"user1",
+			map[string]interface{}{}))
+
+	assert.Error(t, err)
+	assert.Equal(t, true, evalDetails.Value)
+	assert.Equal(t, openfeature.ErrorReason, evalDetails.Reason)
+	assert.Equal(t, openfeature.GeneralCode, evalDetails.ErrorCode)
+	assert.Equal(t, "TIMEOUT: error when resolving, timeout", evalDetails.ErrorMessage)
+}
+
 func client(t *testing.T, response confidence.ResolveResponse, errorToReturn error) *openfeature.Client {
 	resolveClient := MockResolveClient{MockedResponse: response, MockedError: errorToReturn, TestingT: t}
 	conf := confidence.NewConfidenceBuilder().SetAPIConfig(confidence.APIConfig{APIKey: "apiKey"}).SetResolveClient(resolveClient).Build()
-	provider := FlagProvider{
-		confidence: conf,
-	}
-	openfeature.SetProvider(provider)
+	openfeature.SetProviderAndWait(NewFlagProvider(conf))
 	return openfeature.NewClient("testApp")
 }
 
@@ -222,6 +515,7 @@ func templateResponseWithFlagName(flagName string) confidence.ResolveResponse {
 	}
 },
 "boolean-key": true,
+"list-key": [1, 2],
 "string-key": "treatment",
 "double-key": 20.203,
 "integer-key": 40
@@ -258,6 +552,9 @@ func templateResponseWithFlagName(flagName string) confidence.ResolveResponse {
 "boolean-key": {
 "boolSchema": {}
 },
+"list-key": {
+"listSchema": {"elementSchema": {"intSchema": {}}}
+},
 "string-key": {
 "stringSchema": {}
 },